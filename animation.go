@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+)
+
+type AnimMode string
+
+const (
+	AnimLoop     AnimMode = "loop"     // 循环播放，每播完一轮回调一次
+	AnimOnce     AnimMode = "once"     // 播放一次后停在最后一帧并回调
+	AnimPingPong AnimMode = "pingpong" // 正向播放后反向播放，每回到第一帧回调一次
+)
+
+// AnimFrame 动画的一帧，Duration是持续的帧数
+type AnimFrame struct {
+	Sprite   string `json:"sprite"`
+	Duration int    `json:"duration,omitempty"`
+}
+
+// AnimationDef 动画的定义，帧来自图集中的精灵名称
+type AnimationDef struct {
+	Mode   AnimMode    `json:"mode"`
+	Frames []AnimFrame `json:"frames"`
+}
+
+// AnimationDefs 游戏用到的动画，履带印也可以按坦克定义中的履带名称定义动画
+var AnimationDefs = map[string]AnimationDef{
+	"explosion": {Mode: AnimOnce, Frames: []AnimFrame{{"explosion1", 6}, {"explosion2", 6},
+		{"explosion3", 6}, {"explosion4", 6}, {"explosion5", 6}}},
+	"smoke": {Mode: AnimPingPong, Frames: []AnimFrame{{"explosionSmoke1", 12}, {"explosionSmoke2", 12},
+		{"explosionSmoke3", 12}, {"explosionSmoke4", 12}, {"explosionSmoke5", 12}}},
+	"muzzleHero":  {Mode: AnimOnce, Frames: []AnimFrame{{"shotLarge", 2}, {"shotOrange", 3}}},
+	"muzzleEnemy": {Mode: AnimOnce, Frames: []AnimFrame{{"shotLarge", 2}, {"shotRed", 3}}},
+}
+
+// Animation 解析好的动画，可以被多个播放器共用
+type Animation struct {
+	Name      string
+	Mode      AnimMode
+	Frames    []SpriteInfo
+	Durations []int
+}
+
+// NewAnimation 按定义从图集取得每一帧，帧的持续时间至少为1
+func NewAnimation(atlas *Atlas, name string, def AnimationDef) (*Animation, error) {
+	switch def.Mode {
+	case AnimLoop, AnimOnce, AnimPingPong:
+	default:
+		return nil, fmt.Errorf("animation %q: unknown mode %q", name, def.Mode)
+	}
+	if len(def.Frames) == 0 {
+		return nil, fmt.Errorf("animation %q: no frames", name)
+	}
+	anim := &Animation{Name: name, Mode: def.Mode}
+	for _, frame := range def.Frames {
+		info, err := atlas.Sprite(frame.Sprite)
+		if err != nil {
+			return nil, fmt.Errorf("animation %q: %w", name, err)
+		}
+		anim.Frames = append(anim.Frames, info)
+		anim.Durations = append(anim.Durations, max(frame.Duration, 1))
+	}
+	return anim, nil
+}
+
+// Play 创建一个从第一帧开始的播放器
+func (a *Animation) Play(onComplete func()) *Animator {
+	return &Animator{anim: a, onComplete: onComplete}
+}
+
+// Animator 动画的播放器，每次Update前进一帧时间
+type Animator struct {
+	anim       *Animation
+	frame      int
+	tick       int
+	reverse    bool // 往返播放时是否正在反向播放
+	done       bool
+	onComplete func()
+}
+
+func (a *Animator) Frame() SpriteInfo {
+	return a.anim.Frames[a.frame]
+}
+
+// Done 只播放一次的动画是否已经播完
+func (a *Animator) Done() bool {
+	return a.done
+}
+
+// SetFrame 从指定的帧开始播放，让同一动画的多个实例错开
+func (a *Animator) SetFrame(frame int) {
+	a.frame, a.tick = frame%len(a.anim.Frames), 0
+}
+
+func (a *Animator) Update() {
+	if a.done {
+		return
+	}
+	a.tick++
+	if a.tick < a.anim.Durations[a.frame] {
+		return
+	}
+	a.tick = 0
+	last := len(a.anim.Frames) - 1
+	switch a.anim.Mode {
+	case AnimOnce:
+		if a.frame < last {
+			a.frame++
+			return
+		}
+		a.done = true
+	case AnimLoop:
+		if a.frame < last {
+			a.frame++
+			return
+		}
+		a.frame = 0
+	case AnimPingPong:
+		if last == 0 {
+			break
+		}
+		if !a.reverse {
+			a.frame++
+			a.reverse = a.frame == last
+			return
+		}
+		a.frame--
+		if a.frame > 0 {
+			return
+		}
+		a.reverse = false
+	}
+	if a.onComplete != nil {
+		a.onComplete()
+	}
+}
+
+// AnimatedSprite 播放动画的精灵，以中心点定位，尺寸随当前帧变化
+type AnimatedSprite struct {
+	*BoxSprite
+	atlas *Atlas
+	anim  *Animator
+	cx    float64
+	cy    float64
+	scale float64
+}
+
+func NewAnimatedSprite(atlas *Atlas, anim *Animator, cx, cy, scale float64) *AnimatedSprite {
+	s := &AnimatedSprite{BoxSprite: &BoxSprite{}, atlas: atlas, anim: anim, cx: cx, cy: cy, scale: scale}
+	s.sync()
+	return s
+}
+
+func (s *AnimatedSprite) Update() {
+	s.anim.Update()
+	s.sync()
+}
+
+// sync 按当前帧更新图片、尺寸和位置
+func (s *AnimatedSprite) sync() {
+	frame := s.anim.Frame()
+	w, h := frame.Size()
+	s.Img = s.atlas.Image(frame)
+	s.SetPivot(frame)
+	s.W, s.H = float64(w)*s.scale, float64(h)*s.scale
+	s.X, s.Y = s.cx-s.W/2, s.cy-s.H/2
+}
+
+// Animations 按名称注册的动画
+type Animations map[string]*Animation
+
+// LoadAnimations 解析所有动画的定义
+func LoadAnimations(atlas *Atlas) (Animations, error) {
+	animations := make(Animations, len(AnimationDefs))
+	for name, def := range AnimationDefs {
+		anim, err := NewAnimation(atlas, name, def)
+		if err != nil {
+			return nil, err
+		}
+		animations[name] = anim
+	}
+	return animations, nil
+}
+
+// animation 取得注册的动画，没有时把同名的精灵当作只有一帧的循环动画
+func (g *Game) animation(name string) *Animation {
+	if anim, ok := g.animations[name]; ok {
+		return anim
+	}
+	anim, err := NewAnimation(g.atlas, name, AnimationDef{Mode: AnimLoop, Frames: []AnimFrame{{Sprite: name}}})
+	FatalIfError(err)
+	g.animations[name] = anim
+	return anim
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+const DefaultAtlas = "sprites.xml"
+
+//go:embed sprites.png sprites.xml chsfont.ttf hit.ogg explode.ogg
+var assets embed.FS
+
+// SpriteSheet 从图集文件解析出的页面图片和精灵，不依赖图形环境
+type SpriteSheet struct {
+	Pages     []image.Image
+	PagePaths []string
+	Sprites   []SpriteInfo
+}
+
+// LoadSpriteSheet 读取图集文件，支持TexturePacker的JSON(hash和array)和Starling/Kenney的XML，
+// 每个文件可以有多个页面，页面图片的路径相对于图集文件
+func LoadSpriteSheet(fsys fs.FS, paths ...string) (*SpriteSheet, error) {
+	sheet, err := readSpriteSheet(fsys, paths...)
+	if err != nil {
+		return nil, err
+	}
+	for _, sprite := range sheet.Sprites {
+		if !sheet.InBounds(sprite) {
+			return nil, fmt.Errorf("atlas: sprite %q is outside of page %s", sprite.Name, sheet.PagePaths[sprite.Page])
+		}
+	}
+	return sheet, nil
+}
+
+// readSpriteSheet 读取图集文件，不检查精灵是否超出页面
+func readSpriteSheet(fsys fs.FS, paths ...string) (*SpriteSheet, error) {
+	sheet := &SpriteSheet{}
+	names := map[string]string{}
+	loaded := map[string]bool{} // 多页面的图集文件互相关联，每个文件只读一次
+	for len(paths) > 0 {
+		file := path.Clean(paths[0])
+		paths = paths[1:]
+		if loaded[file] {
+			continue
+		}
+		loaded[file] = true
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("atlas: %w", err)
+		}
+		var pages []atlasPage
+		var related []string
+		if strings.EqualFold(path.Ext(file), ".xml") {
+			pages, err = parseStarlingAtlas(data)
+		} else {
+			pages, related, err = parseTexturePackerAtlas(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("atlas %s: %w", file, err)
+		}
+		for _, other := range related {
+			paths = append(paths, path.Join(path.Dir(file), other))
+		}
+		for _, page := range pages {
+			pagePath := path.Join(path.Dir(file), page.image)
+			img, err := decodeImage(fsys, pagePath)
+			if err != nil {
+				return nil, fmt.Errorf("atlas %s: page %w", file, err)
+			}
+			for _, sprite := range page.sprites {
+				if prev, ok := names[sprite.Name]; ok {
+					return nil, fmt.Errorf("atlas %s: sprite %q already defined in %s", file, sprite.Name, prev)
+				}
+				names[sprite.Name] = file
+				sprite.Page = len(sheet.Pages)
+				sheet.Sprites = append(sheet.Sprites, sprite)
+			}
+			sheet.Pages = append(sheet.Pages, img)
+			sheet.PagePaths = append(sheet.PagePaths, pagePath)
+		}
+	}
+	sort.Slice(sheet.Sprites, func(i, j int) bool {
+		return sheet.Sprites[i].Name < sheet.Sprites[j].Name
+	})
+	return sheet, nil
+}
+
+// Rect 精灵在页面中的区域
+func (info SpriteInfo) Rect() image.Rectangle {
+	return image.Rect(info.X, info.Y, info.X+info.Width, info.Y+info.Height)
+}
+
+// InBounds 精灵是否在页面之内
+func (s *SpriteSheet) InBounds(info SpriteInfo) bool {
+	return info.Rect().In(s.Pages[info.Page].Bounds())
+}
+
+// Overlaps 同一页面中区域重叠的精灵
+func (s *SpriteSheet) Overlaps() [][2]SpriteInfo {
+	var overlaps [][2]SpriteInfo
+	for i, a := range s.Sprites {
+		for _, b := range s.Sprites[i+1:] {
+			if a.Page == b.Page && a.Rect().Overlaps(b.Rect()) {
+				overlaps = append(overlaps, [2]SpriteInfo{a, b})
+			}
+		}
+	}
+	return overlaps
+}
+
+func decodeImage(fsys fs.FS, name string) (image.Image, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, nil
+}
+
+// atlasPage 图集文件中的一个页面
+type atlasPage struct {
+	image   string
+	sprites []SpriteInfo
+}
+
+// spriteName 去掉图片扩展名作为精灵的名称
+func spriteName(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return strings.TrimSuffix(name, path.Ext(name))
+	}
+	return name
+}
+
+type starlingAtlas struct {
+	ImagePath   string `xml:"imagePath,attr"`
+	SubTextures []struct {
+		Name        string   `xml:"name,attr"`
+		X           int      `xml:"x,attr"`
+		Y           int      `xml:"y,attr"`
+		Width       int      `xml:"width,attr"`
+		Height      int      `xml:"height,attr"`
+		FrameX      int      `xml:"frameX,attr"`
+		FrameY      int      `xml:"frameY,attr"`
+		FrameWidth  int      `xml:"frameWidth,attr"`
+		FrameHeight int      `xml:"frameHeight,attr"`
+		PivotX      *float64 `xml:"pivotX,attr"`
+		PivotY      *float64 `xml:"pivotY,attr"`
+		Rotated     bool     `xml:"rotated,attr"`
+	} `xml:"SubTexture"`
+}
+
+func parseStarlingAtlas(data []byte) ([]atlasPage, error) {
+	var atlas starlingAtlas
+	if err := xml.Unmarshal(data, &atlas); err != nil {
+		return nil, err
+	}
+	if atlas.ImagePath == "" {
+		return nil, fmt.Errorf("missing imagePath")
+	}
+	page := atlasPage{image: atlas.ImagePath}
+	for _, st := range atlas.SubTextures {
+		if st.Rotated {
+			return nil, fmt.Errorf("sprite %q: rotated sprites are not supported", st.Name)
+		}
+		sprite := SpriteInfo{Name: spriteName(st.Name), X: st.X, Y: st.Y, Width: st.Width, Height: st.Height,
+			PivotX: 0.5, PivotY: 0.5}
+		if st.FrameWidth > 0 && st.FrameHeight > 0 {
+			// Starling的frameX和frameY是原图相对裁剪区域的位置，所以是负数
+			sprite.trim(-st.FrameX, -st.FrameY, st.FrameWidth, st.FrameHeight)
+		}
+		if st.PivotX != nil && st.PivotY != nil {
+			w, h := sprite.Size()
+			sprite.PivotX, sprite.PivotY = *st.PivotX/float64(w), *st.PivotY/float64(h)
+		}
+		if err := sprite.validate(); err != nil {
+			return nil, err
+		}
+		page.sprites = append(page.sprites, sprite)
+	}
+	return []atlasPage{page}, nil
+}
+
+type texturePackerRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerFrame struct {
+	Filename         string            `json:"filename"`
+	Frame            texturePackerRect `json:"frame"`
+	Rotated          bool              `json:"rotated"`
+	Trimmed          bool              `json:"trimmed"`
+	SpriteSourceSize texturePackerRect `json:"spriteSourceSize"`
+	SourceSize       texturePackerRect `json:"sourceSize"`
+	Pivot            *struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"pivot,omitempty"`
+}
+
+type texturePackerMeta struct {
+	Image             string   `json:"image"`
+	RelatedMultiPacks []string `json:"related_multi_packs"`
+}
+
+type texturePackerAtlas struct {
+	Frames   json.RawMessage   `json:"frames"`
+	Meta     texturePackerMeta `json:"meta"`
+	Textures []struct {
+		Image  string               `json:"image"`
+		Frames []texturePackerFrame `json:"frames"`
+	} `json:"textures"` // Phaser 3的多页面格式
+}
+
+// parseTexturePackerAtlas 解析TexturePacker的JSON，frames可以是以名称为键的对象或带filename的数组，
+// 同时返回meta中关联的其他页面的图集文件
+func parseTexturePackerAtlas(data []byte) ([]atlasPage, []string, error) {
+	var atlas texturePackerAtlas
+	if err := json.Unmarshal(data, &atlas); err != nil {
+		return nil, nil, err
+	}
+	var pages []atlasPage
+	for _, texture := range atlas.Textures {
+		page, err := texturePackerPage(texture.Image, texture.Frames)
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, page)
+	}
+	if len(atlas.Frames) > 0 && string(atlas.Frames) != "null" {
+		var frames []texturePackerFrame
+		if atlas.Frames[0] == '{' {
+			var hash map[string]texturePackerFrame
+			if err := json.Unmarshal(atlas.Frames, &hash); err != nil {
+				return nil, nil, err
+			}
+			for name, frame := range hash {
+				frame.Filename = name
+				frames = append(frames, frame)
+			}
+		} else if err := json.Unmarshal(atlas.Frames, &frames); err != nil {
+			return nil, nil, err
+		}
+		page, err := texturePackerPage(atlas.Meta.Image, frames)
+		if err != nil {
+			return nil, nil, err
+		}
+		pages = append(pages, page)
+	}
+	if len(pages) == 0 {
+		return nil, nil, fmt.Errorf("no frames or textures")
+	}
+	return pages, atlas.Meta.RelatedMultiPacks, nil
+}
+
+func texturePackerPage(image string, frames []texturePackerFrame) (atlasPage, error) {
+	if image == "" {
+		return atlasPage{}, fmt.Errorf("missing page image")
+	}
+	page := atlasPage{image: image}
+	for _, frame := range frames {
+		if frame.Rotated {
+			return page, fmt.Errorf("sprite %q: rotated sprites are not supported", frame.Filename)
+		}
+		sprite := SpriteInfo{Name: spriteName(frame.Filename),
+			X: frame.Frame.X, Y: frame.Frame.Y, Width: frame.Frame.W, Height: frame.Frame.H, PivotX: 0.5, PivotY: 0.5}
+		if frame.Trimmed {
+			sprite.trim(frame.SpriteSourceSize.X, frame.SpriteSourceSize.Y, frame.SourceSize.W, frame.SourceSize.H)
+		}
+		if frame.Pivot != nil {
+			sprite.PivotX, sprite.PivotY = frame.Pivot.X, frame.Pivot.Y
+		}
+		if err := sprite.validate(); err != nil {
+			return page, err
+		}
+		page.sprites = append(page.sprites, sprite)
+	}
+	return page, nil
+}
+
+// Atlas 图集，按名称查找精灵并取得精灵的图片
+type Atlas struct {
+	Pages   []*ebiten.Image
+	sheet   *SpriteSheet
+	sprites map[string]SpriteInfo
+	images  map[string]*ebiten.Image // 裁剪过的精灵还原为原图尺寸后的图片
+}
+
+func NewAtlas(sheet *SpriteSheet) *Atlas {
+	a := &Atlas{sheet: sheet, sprites: make(map[string]SpriteInfo, len(sheet.Sprites)),
+		images: map[string]*ebiten.Image{}}
+	for _, page := range sheet.Pages {
+		a.Pages = append(a.Pages, ebiten.NewImageFromImage(page))
+	}
+	for _, sprite := range sheet.Sprites {
+		a.sprites[sprite.Name] = sprite
+	}
+	return a
+}
+
+func (a *Atlas) Sheet() *SpriteSheet {
+	return a.sheet
+}
+
+// Sprite 按名称查找精灵
+func (a *Atlas) Sprite(name string) (SpriteInfo, error) {
+	sprite, ok := a.sprites[name]
+	if !ok {
+		return sprite, fmt.Errorf("atlas: unknown sprite %q", name)
+	}
+	return sprite, nil
+}
+
+// Image 精灵的图片，裁剪过的精灵还原为原图的尺寸
+func (a *Atlas) Image(info SpriteInfo) *ebiten.Image {
+	frame := a.Pages[info.Page].SubImage(image.Rect(info.X, info.Y,
+		info.X+info.Width, info.Y+info.Height)).(*ebiten.Image)
+	if !info.Trimmed() {
+		return frame
+	}
+	img, ok := a.images[info.Name]
+	if !ok {
+		img = ebiten.NewImage(info.SourceWidth, info.SourceHeight)
+		options := &ebiten.DrawImageOptions{}
+		options.GeoM.Translate(float64(info.OffsetX), float64(info.OffsetY))
+		img.DrawImage(frame, options)
+		a.images[info.Name] = img
+	}
+	return img
+}
+
+// sprite 按名称查找精灵，找不到时退出
+func (g *Game) sprite(name string) SpriteInfo {
+	info, err := g.atlas.Sprite(name)
+	FatalIfError(err)
+	return info
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"golang.org/x/image/colornames"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ContactCell    = 128 // 缩略图的最大边长
+	ContactLabel   = 32  // 缩略图下方文字的高度
+	ContactColumns = 8
+)
+
+const atlasUsage = `usage: go-tank atlas <command> [flags]
+
+commands:
+  sheet  render an annotated contact sheet of every sprite
+  list   list sprite names, pages and sizes
+  check  report overlapping and out-of-bounds sprite rects
+  pack   pack a folder of PNGs into an atlas image and a JSON descriptor
+
+sheet, list and check read the embedded atlas unless -atlas is given.
+run "go-tank atlas <command> -h" for the flags of a command.
+`
+
+// atlasCommand 图集工具的命令行入口，不打开窗口，返回进程的退出码
+func atlasCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, atlasUsage)
+		return 2
+	}
+	commands := map[string]func([]string) error{
+		"sheet": atlasSheet,
+		"list":  atlasList,
+		"check": atlasCheck,
+		"pack":  atlasPack,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown atlas command %q\n\n%s", args[0], atlasUsage)
+		return 2
+	}
+	if err := command(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "atlas "+args[0]+":", err)
+		return 1
+	}
+	return 0
+}
+
+// atlasFlags 读取图集的命令共用的参数
+func atlasFlags(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("atlas "+name, flag.ContinueOnError)
+	return flags, flags.String("atlas", "", "atlas descriptor (TexturePacker JSON or Starling XML), default is the embedded atlas")
+}
+
+// readAtlasFile 读取命令行指定的图集，不检查精灵是否超出页面，由调用者报告
+func readAtlasFile(file string) (*SpriteSheet, error) {
+	var fsys fs.FS = assets
+	if file == "" {
+		file = DefaultAtlas
+	} else {
+		fsys = os.DirFS(filepath.Dir(file))
+		file = filepath.Base(file)
+	}
+	return readSpriteSheet(fsys, file)
+}
+
+func atlasList(args []string) error {
+	flags, file := atlasFlags("list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	sheet, err := readAtlasFile(*file)
+	if err != nil {
+		return err
+	}
+	for _, sprite := range sheet.Sprites {
+		w, h := sprite.Size()
+		line := fmt.Sprintf("%-32s page %d  %4d,%-4d %4dx%-4d", sprite.Name, sprite.Page,
+			sprite.X, sprite.Y, sprite.Width, sprite.Height)
+		if sprite.Trimmed() {
+			line += fmt.Sprintf("  source %dx%d offset %d,%d", w, h, sprite.OffsetX, sprite.OffsetY)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d sprites on %d pages\n", len(sheet.Sprites), len(sheet.Pages))
+	return nil
+}
+
+func atlasCheck(args []string) error {
+	flags, file := atlasFlags("check")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	sheet, err := readAtlasFile(*file)
+	if err != nil {
+		return err
+	}
+	problems := 0
+	for _, sprite := range sheet.Sprites {
+		if !sheet.InBounds(sprite) {
+			fmt.Printf("out of bounds: %s %v is outside of page %s %v\n", sprite.Name, sprite.Rect(),
+				sheet.PagePaths[sprite.Page], sheet.Pages[sprite.Page].Bounds())
+			problems++
+		}
+	}
+	for _, pair := range sheet.Overlaps() {
+		fmt.Printf("overlap: %s %v and %s %v on page %s\n", pair[0].Name, pair[0].Rect(),
+			pair[1].Name, pair[1].Rect(), sheet.PagePaths[pair[0].Page])
+		problems++
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
+	fmt.Printf("%d sprites on %d pages, no problems found\n", len(sheet.Sprites), len(sheet.Pages))
+	return nil
+}
+
+func atlasSheet(args []string) error {
+	flags, file := atlasFlags("sheet")
+	output := flags.String("o", "contact.png", "output PNG file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	sheet, err := readAtlasFile(*file)
+	if err != nil {
+		return err
+	}
+	face, err := labelFace()
+	if err != nil {
+		return err
+	}
+	rows := (len(sheet.Sprites) + ContactColumns - 1) / ContactColumns
+	cellW, cellH := ContactCell+16, ContactCell+ContactLabel+16
+	img := image.NewRGBA(image.Rect(0, 0, cellW*ContactColumns, cellH*max(rows, 1)))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}), image.Point{}, draw.Src)
+	for i, sprite := range sheet.Sprites {
+		x, y := i%ContactColumns*cellW+8, i/ContactColumns*cellH+8
+		cell := image.Rect(x, y, x+ContactCell, y+ContactCell)
+		draw.Draw(img, cell, image.NewUniform(color.RGBA{R: 0x48, G: 0x48, B: 0x48, A: 0xff}), image.Point{}, draw.Src)
+
+		// 大的精灵等比缩小，小的保持原尺寸，都放在格子中央
+		src := sheet.Pages[sprite.Page]
+		rect := sprite.Rect().Intersect(src.Bounds())
+		scale := min(1, float64(ContactCell)/float64(max(rect.Dx(), rect.Dy(), 1)))
+		w, h := int(float64(rect.Dx())*scale), int(float64(rect.Dy())*scale)
+		dst := image.Rect(x+(ContactCell-w)/2, y+(ContactCell-h)/2, x+(ContactCell+w)/2, y+(ContactCell+h)/2)
+		xdraw.ApproxBiLinear.Scale(img, dst, src, rect, draw.Over, nil)
+
+		clr := colornames.Lightyellow
+		if !sheet.InBounds(sprite) {
+			clr = colornames.Orangered
+		}
+		drawLabel(img, face, sprite.Name, x, y+ContactCell+14, clr)
+		drawLabel(img, face, fmt.Sprintf("%dx%d p%d", sprite.Width, sprite.Height, sprite.Page),
+			x, y+ContactCell+28, colornames.Lightgray)
+	}
+	if err = writePNG(*output, img); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s with %d sprites\n", *output, len(sheet.Sprites))
+	return nil
+}
+
+// labelFace 内置字体的小号字
+func labelFace() (font.Face, error) {
+	data, err := assets.ReadFile("chsfont.ttf")
+	if err != nil {
+		return nil, err
+	}
+	ttf, err := opentype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(ttf, &opentype.FaceOptions{Size: 11, DPI: 72, Hinting: font.HintingFull})
+}
+
+// drawLabel 绘制文字，超出格子宽度时截断
+func drawLabel(img draw.Image, face font.Face, label string, x, y int, clr color.Color) {
+	for runes := []rune(label); font.MeasureString(face, label).Ceil() > ContactCell && len(runes) > 1; {
+		runes = runes[:len(runes)-1]
+		label = string(runes) + "…"
+	}
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(clr), Face: face, Dot: fixed.P(x, y)}
+	drawer.DrawString(label)
+}
+
+func writePNG(name string, img image.Image) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(file, img); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// packItem 等待打包的一张图片
+type packItem struct {
+	name   string
+	img    image.Image
+	rect   image.Rectangle // 裁剪后在原图中的区域
+	x, y   int             // 在图集中的位置
+	source image.Rectangle
+}
+
+func atlasPack(args []string) error {
+	flags := flag.NewFlagSet("atlas pack", flag.ContinueOnError)
+	output := flags.String("o", "atlas.png", "output PNG file, the JSON descriptor is written next to it")
+	maxWidth := flags.Int("width", 2048, "maximum atlas width")
+	padding := flags.Int("padding", 2, "pixels between sprites")
+	trim := flags.Bool("trim", false, "trim transparent edges")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-tank atlas pack [flags] <dir>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing input directory")
+	}
+	items, err := readPackItems(flags.Arg(0), *trim)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no PNG files in %s", flags.Arg(0))
+	}
+	width, height, err := packShelves(items, *maxWidth, *padding)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	frames := make([]texturePackerFrame, 0, len(items))
+	for _, item := range items {
+		dst := image.Rect(item.x, item.y, item.x+item.rect.Dx(), item.y+item.rect.Dy())
+		draw.Draw(img, dst, item.img, item.rect.Min, draw.Src)
+		frames = append(frames, texturePackerFrame{
+			Filename: item.name,
+			Frame:    texturePackerRect{X: dst.Min.X, Y: dst.Min.Y, W: dst.Dx(), H: dst.Dy()},
+			Trimmed:  item.rect != item.source,
+			SpriteSourceSize: texturePackerRect{X: item.rect.Min.X - item.source.Min.X,
+				Y: item.rect.Min.Y - item.source.Min.Y, W: item.rect.Dx(), H: item.rect.Dy()},
+			SourceSize: texturePackerRect{W: item.source.Dx(), H: item.source.Dy()},
+		})
+	}
+	if err = writePNG(*output, img); err != nil {
+		return err
+	}
+	descriptor := struct {
+		Frames []texturePackerFrame `json:"frames"`
+		Meta   texturePackerMeta    `json:"meta"`
+	}{frames, texturePackerMeta{Image: filepath.Base(*output)}}
+	data, err := json.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return err
+	}
+	jsonFile := strings.TrimSuffix(*output, filepath.Ext(*output)) + ".json"
+	if err = os.WriteFile(jsonFile, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("packed %d sprites into %s (%dx%d) and %s\n", len(items), *output, width, height, jsonFile)
+	return nil
+}
+
+// readPackItems 读取目录下所有的PNG，名称是不带扩展名的相对路径
+func readPackItems(dir string, trim bool) ([]*packItem, error) {
+	var items []*packItem
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".png") {
+			return err
+		}
+		img, err := decodeImage(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		item := &packItem{name: filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))),
+			img: img, rect: img.Bounds(), source: img.Bounds()}
+		if trim {
+			item.rect = opaqueBounds(img)
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// opaqueBounds 去掉透明边缘后的区域，全透明时保留一个像素
+func opaqueBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	rect := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				rect = rect.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if rect.Empty() {
+		return image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1)
+	}
+	return rect
+}
+
+// packShelves 按高度从高到低逐行摆放，返回图集的尺寸
+func packShelves(items []*packItem, maxWidth, padding int) (int, int, error) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].rect.Dy() != items[j].rect.Dy() {
+			return items[i].rect.Dy() > items[j].rect.Dy()
+		}
+		return items[i].name < items[j].name
+	})
+	x, y, shelf, width := 0, 0, 0, 0
+	for _, item := range items {
+		w, h := item.rect.Dx(), item.rect.Dy()
+		if w > maxWidth {
+			return 0, 0, fmt.Errorf("%s is %d pixels wide, wider than the atlas width %d", item.name, w, maxWidth)
+		}
+		if x > 0 && x+w > maxWidth {
+			x, y, shelf = 0, y+shelf+padding, 0
+		}
+		item.x, item.y = x, y
+		x += w + padding
+		shelf = max(shelf, h)
+		width = max(width, item.x+w)
+	}
+	return width, y + shelf, nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SampleRate  = 48000
+	AudioRange  = 1200 // 超过这个距离听不到音效
+	MusicDuck   = 0.3  // 暂停时音乐音量的比例
+	DuckSmooth  = 0.05 // 音乐音量趋近目标的比例
+	VolumeStep  = 0.1
+	VoicesPerFx = 4 // 每种音效同时播放的数量
+)
+
+// Voice 一个可播放的声音
+type Voice interface {
+	Play()
+	Pause()
+	Rewind() error
+	IsPlaying() bool
+	SetVolume(volume float64)
+	SetPan(pan float64) // -1为左声道，1为右声道
+	Current() time.Duration
+	Close() error
+}
+
+// AudioBackend 创建声音的后端，无界面运行时使用空后端
+type AudioBackend interface {
+	// Decode 按文件扩展名把OGG、MP3或WAV解码为PCM流，同时返回PCM的字节数，未知时为0
+	Decode(name string, src io.ReadSeeker) (io.ReadSeeker, int64, error)
+	NewVoice(pcm io.ReadSeeker) Voice
+}
+
+// NewAudioBackend 环境变量GO_TANK_AUDIO为off时使用空后端
+func NewAudioBackend() AudioBackend {
+	if os.Getenv("GO_TANK_AUDIO") == "off" {
+		return nullBackend{}
+	}
+	return &ebitenBackend{ctx: audio.NewContext(SampleRate)}
+}
+
+type ebitenBackend struct {
+	ctx *audio.Context
+}
+
+func (b *ebitenBackend) Decode(name string, src io.ReadSeeker) (io.ReadSeeker, int64, error) {
+	return decodeSound(name, src)
+}
+
+// decodeSound 按文件扩展名解码，不需要音频上下文，加载皮肤时也用它检查音效
+func decodeSound(name string, src io.ReadSeeker) (io.ReadSeeker, int64, error) {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".ogg":
+		stream, err := vorbis.DecodeWithSampleRate(SampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+		return stream, stream.Length(), nil
+	case ".mp3":
+		stream, err := mp3.DecodeWithSampleRate(SampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+		return stream, stream.Length(), nil
+	case ".wav":
+		stream, err := wav.DecodeWithSampleRate(SampleRate, src)
+		if err != nil {
+			return nil, 0, err
+		}
+		return stream, stream.Length(), nil
+	default:
+		return nil, 0, fmt.Errorf("%s: unsupported audio format %q", name, ext)
+	}
+}
+
+func (b *ebitenBackend) NewVoice(pcm io.ReadSeeker) Voice {
+	stream := &panStream{ReadSeeker: pcm}
+	player, err := b.ctx.NewPlayer(stream)
+	FatalIfError(err)
+	return &ebitenVoice{Player: player, stream: stream}
+}
+
+type ebitenVoice struct {
+	*audio.Player
+	stream *panStream
+}
+
+func (v *ebitenVoice) SetPan(pan float64) {
+	v.stream.SetPan(pan)
+}
+
+// panStream 按声像调整左右声道的16位立体声PCM流
+type panStream struct {
+	io.ReadSeeker
+	lock sync.Mutex
+	pan  float64
+}
+
+func (s *panStream) SetPan(pan float64) {
+	s.lock.Lock()
+	s.pan = math.Max(-1, math.Min(pan, 1))
+	s.lock.Unlock()
+}
+
+func (s *panStream) Read(p []byte) (int, error) {
+	n, err := s.ReadSeeker.Read(p)
+	s.lock.Lock()
+	pan := s.pan
+	s.lock.Unlock()
+	if pan == 0 {
+		return n, err
+	}
+	left, right := math.Min(1, 1-pan), math.Min(1, 1+pan)
+	for i := 0; i+4 <= n; i += 4 {
+		l := float64(int16(p[i]) | int16(p[i+1])<<8)
+		r := float64(int16(p[i+2]) | int16(p[i+3])<<8)
+		lv, rv := int16(l*left), int16(r*right)
+		p[i], p[i+1] = byte(lv), byte(lv>>8)
+		p[i+2], p[i+3] = byte(rv), byte(rv>>8)
+	}
+	return n, err
+}
+
+type nullBackend struct{}
+
+func (nullBackend) Decode(string, io.ReadSeeker) (io.ReadSeeker, int64, error) {
+	return bytes.NewReader(nil), 0, nil
+}
+
+func (nullBackend) NewVoice(io.ReadSeeker) Voice { return nullVoice{} }
+
+type nullVoice struct{}
+
+func (nullVoice) Play()                  {}
+func (nullVoice) Pause()                 {}
+func (nullVoice) Rewind() error          { return nil }
+func (nullVoice) IsPlaying() bool        { return false }
+func (nullVoice) SetVolume(float64)      {}
+func (nullVoice) SetPan(float64)         {}
+func (nullVoice) Current() time.Duration { return 0 }
+func (nullVoice) Close() error           { return nil }
+
+// Sound 一种音效的声音池，重叠播放时不会打断正在播放的声音
+type Sound struct {
+	voices []Voice
+	next   int
+	volume float64
+}
+
+// voice 取一个空闲的声音，全在播放时复用最早开始的
+func (s *Sound) voice() Voice {
+	for i := range s.voices {
+		v := s.voices[(s.next+i)%len(s.voices)]
+		if !v.IsPlaying() {
+			return v
+		}
+	}
+	v := s.voices[s.next]
+	s.next = (s.next + 1) % len(s.voices)
+	return v
+}
+
+// Audio 音频管理，音效按与英雄的距离衰减和左右声像，音乐在暂停时压低音量，
+// 主音量、音乐和音效音量保存在设置中，N键静音，[和]键调整主音量
+type Audio struct {
+	game     *Game
+	backend  AudioBackend
+	settings *Settings
+	sounds   map[string]*Sound
+	duck     float64 // 当前音乐音量的比例
+}
+
+func NewAudio(g *Game, backend AudioBackend, settings *Settings, bus *EventBus) *Audio {
+	a := &Audio{game: g, backend: backend, settings: settings, sounds: map[string]*Sound{}, duck: 1}
+	Subscribe(bus, func(e TankHit) {
+		if e.Tank.life > 0 {
+			a.PlayAt("hit", e.Tank.BoxSprite)
+		}
+	})
+	Subscribe(bus, func(e TankDestroyed) {
+		a.PlayAt("explode", e.Tank.BoxSprite)
+	})
+	return a
+}
+
+// Load 解码音效并创建声音池
+func (a *Audio) Load(name string, sound SkinSound, volume float64) {
+	stream, _, err := a.backend.Decode(sound.File, bytes.NewReader(sound.Data))
+	FatalIfError(err)
+	pcm, err := io.ReadAll(stream)
+	FatalIfError(err)
+	pool := &Sound{volume: volume}
+	for i := 0; i < VoicesPerFx; i++ {
+		pool.voices = append(pool.voices, a.backend.NewVoice(bytes.NewReader(pcm)))
+	}
+	a.sounds[name] = pool
+}
+
+// Play 不受距离影响地播放音效
+func (a *Audio) Play(name string) {
+	a.play(name, 1, 0)
+}
+
+// PlayAt 在精灵的位置播放音效
+func (a *Audio) PlayAt(name string, s *BoxSprite) {
+	hero := a.game.hero
+	dx := s.X + s.W/2 - (hero.X + hero.W/2)
+	dy := s.Y + s.H/2 - (hero.Y + hero.H/2)
+	fade := 1 - math.Hypot(dx, dy)/AudioRange
+	if fade <= 0 {
+		return
+	}
+	a.play(name, fade, dx/(a.game.camera.W/2))
+}
+
+func (a *Audio) play(name string, fade, pan float64) {
+	sound, ok := a.sounds[name]
+	if !ok || a.settings.Muted {
+		return
+	}
+	v := sound.voice()
+	_ = v.Rewind()
+	v.SetVolume(sound.volume * fade * a.settings.SFXVolume * a.settings.MasterVolume)
+	v.SetPan(pan)
+	v.Play()
+}
+
+// MusicVolume 音乐的音量，静音和暂停压低都已计入
+func (a *Audio) MusicVolume() float64 {
+	if a.settings.Muted {
+		return 0
+	}
+	return a.settings.MusicVolume * a.settings.MasterVolume * a.duck
+}
+
+func (a *Audio) Update() {
+	changed := false
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		a.settings.Muted = !a.settings.Muted
+		changed = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		a.settings.MasterVolume = math.Max(0, a.settings.MasterVolume-VolumeStep)
+		changed = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		a.settings.MasterVolume = math.Min(1, a.settings.MasterVolume+VolumeStep)
+		changed = true
+	}
+	if changed {
+		a.settings.Save()
+	}
+
+	target := 1.0
+	if a.game.pause {
+		target = MusicDuck
+	}
+	a.duck += (target - a.duck) * DuckSmooth
+}
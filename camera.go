@@ -0,0 +1,114 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"math"
+	"math/rand"
+)
+
+const (
+	CameraSmooth     = 0.12 // 镜头跟随的平滑系数，1为立即跟随
+	CameraShakeDecay = 0.88 // 镜头震动每帧的衰减
+	ExplodeShake     = 12   // 坦克爆炸时的震动幅度
+)
+
+// Camera 镜头，负责世界坐标到屏幕坐标的变换
+type Camera struct {
+	X      float64 // 视口左上角的世界坐标
+	Y      float64
+	W      float64 // 视口尺寸
+	H      float64
+	worldW float64 // 世界尺寸，镜头不会超出世界
+	worldH float64
+	shake  float64 // 当前震动幅度
+	shakeX float64 // 当前帧的震动偏移
+	shakeY float64
+}
+
+func NewCamera(w, h, worldW, worldH int) *Camera {
+	return &Camera{
+		W:      float64(w),
+		H:      float64(h),
+		worldW: float64(worldW),
+		worldH: float64(worldH),
+	}
+}
+
+// Follow 平滑跟随目标，多个目标时跟随其中心
+func (c *Camera) Follow(targets ...*BoxSprite) {
+	if x, y, ok := c.focus(targets); ok {
+		c.X += (x - c.X) * CameraSmooth
+		c.Y += (y - c.Y) * CameraSmooth
+		c.clamp()
+	}
+
+	// 震动逐帧衰减
+	if c.shake > 0.5 {
+		c.shakeX = (rand.Float64()*2 - 1) * c.shake
+		c.shakeY = (rand.Float64()*2 - 1) * c.shake
+		c.shake *= CameraShakeDecay
+	} else {
+		c.shake, c.shakeX, c.shakeY = 0, 0, 0
+	}
+}
+
+// LookAt 立即对准目标，用于重开等场景切换
+func (c *Camera) LookAt(targets ...*BoxSprite) {
+	if x, y, ok := c.focus(targets); ok {
+		c.X, c.Y = x, y
+		c.clamp()
+	}
+	c.shake, c.shakeX, c.shakeY = 0, 0, 0
+}
+
+// Shake 震动镜头，取当前和新幅度中的较大者
+func (c *Camera) Shake(power float64) {
+	c.shake = math.Max(c.shake, power)
+}
+
+// Apply 把世界坐标的变换追加为屏幕坐标的变换
+func (c *Camera) Apply(geoM *ebiten.GeoM) {
+	x, y := c.offset()
+	geoM.Translate(-x, -y)
+}
+
+// ToScreen 世界坐标转换为屏幕坐标
+func (c *Camera) ToScreen(x, y float64) (float64, float64) {
+	ox, oy := c.offset()
+	return x - ox, y - oy
+}
+
+// InView 世界中的矩形是否在视口内，用于裁剪绘制
+func (c *Camera) InView(x, y, w, h float64) bool {
+	return x+w >= c.X-c.shake && x <= c.X+c.W+c.shake &&
+		y+h >= c.Y-c.shake && y <= c.Y+c.H+c.shake
+}
+
+// focus 计算让目标中心居中的视口位置
+func (c *Camera) focus(targets []*BoxSprite) (x, y float64, ok bool) {
+	n := 0
+	for _, target := range targets {
+		if target == nil {
+			continue
+		}
+		w, h := target.GetDrawWH()
+		x += target.X + w/2
+		y += target.Y + h/2
+		n++
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return x/float64(n) - c.W/2, y/float64(n) - c.H/2, true
+}
+
+func (c *Camera) clamp() {
+	c.X = math.Max(0, math.Min(c.X, c.worldW-c.W))
+	c.Y = math.Max(0, math.Min(c.Y, c.worldH-c.H))
+}
+
+// offset 叠加震动后的视口位置，同样不超出世界
+func (c *Camera) offset() (float64, float64) {
+	return math.Max(0, math.Min(c.X+c.shakeX, c.worldW-c.W)),
+		math.Max(0, math.Min(c.Y+c.shakeY, c.worldH-c.H))
+}
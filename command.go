@@ -0,0 +1,157 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+)
+
+const (
+	CommandRadius = 80 // 命令选项离菜单中心的距离
+	CommandDead   = 30 // 鼠标离中心小于这个距离时不选择
+	PickRadius    = 60 // 右键点击离坦克中心多近算选中
+)
+
+// CommandOffsets 命令选项相对菜单中心的方向，上方跟随，左侧坚守，右侧攻击
+var CommandOffsets = [...][2]float64{OrderFollow: {0, -1}, OrderHold: {-1, 0}, OrderAttack: {1, 0}}
+
+// CommandMenu 指挥僚机的环形菜单，按住Q键或手柄左肩键打开，
+// 用鼠标、方向键或左摇杆选择，松开时下达命令；右键点击敌对坦克命令僚机攻击它
+type CommandMenu struct {
+	game   *Game
+	open   bool
+	choice int // 选中的命令，-1表示取消
+	mouseX int // 打开菜单时的鼠标位置，鼠标移动后才用鼠标选择
+	mouseY int
+}
+
+func NewCommandMenu(g *Game) *CommandMenu {
+	return &CommandMenu{game: g, choice: -1}
+}
+
+// Open 菜单打开时英雄不移动，方向键用于选择
+func (c *CommandMenu) Open() bool {
+	return c.open
+}
+
+func (c *CommandMenu) Update() {
+	g := c.game
+	if g.wingmen == 0 || g.hero.life <= 0 {
+		c.open = false
+		return
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		mx, my := ebiten.CursorPosition()
+		if target := c.pick(float64(mx), float64(my)); target != nil {
+			g.Command(OrderAttack, target)
+			g.ShowText(OrderLabels[OrderAttack], colornames.Limegreen)
+		}
+	}
+	held := ebiten.IsKeyPressed(ebiten.KeyQ) ||
+		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonFrontTopLeft)
+	if held {
+		if !c.open {
+			c.open, c.choice = true, -1
+			c.mouseX, c.mouseY = ebiten.CursorPosition()
+		}
+		if choice, ok := c.direction(); ok {
+			c.choice = choice
+		}
+		return
+	}
+	if !c.open {
+		return
+	}
+	c.open = false
+	switch Order(c.choice) {
+	case OrderFollow, OrderHold:
+		g.Command(Order(c.choice), nil)
+		g.ShowText(OrderLabels[c.choice], colornames.Limegreen)
+	case OrderAttack:
+		if target := g.hero.nearestHostile(); target != nil {
+			g.Command(OrderAttack, target)
+			g.ShowText(OrderLabels[c.choice], colornames.Limegreen)
+		} else {
+			g.ShowText("没有目标", colornames.Gray)
+		}
+	}
+}
+
+// direction 当前输入指向的命令，向下表示取消
+func (c *CommandMenu) direction() (int, bool) {
+	var dx, dy float64
+	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyUp) {
+		dy = -1
+	} else if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown) {
+		dy = 1
+	} else if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		dx = -1
+	} else if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight) {
+		dx = 1
+	} else {
+		dx = ebiten.StandardGamepadAxisValue(GamepadID, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		dy = ebiten.StandardGamepadAxisValue(GamepadID, ebiten.StandardGamepadAxisLeftStickVertical)
+		if math.Hypot(dx, dy) < 0.4 {
+			dx, dy = 0, 0
+			if mx, my := ebiten.CursorPosition(); mx != c.mouseX || my != c.mouseY {
+				cx, cy := c.center()
+				dx, dy = float64(mx)-cx, float64(my)-cy
+			}
+			if math.Hypot(dx, dy) < CommandDead {
+				return 0, false
+			}
+		}
+	}
+	if dy > math.Abs(dx) {
+		return -1, true
+	}
+	best, choice := math.Inf(-1), -1
+	for i, offset := range CommandOffsets {
+		if dot := offset[0]*dx + offset[1]*dy; dot > best {
+			best, choice = dot, i
+		}
+	}
+	return choice, true
+}
+
+// pick 屏幕坐标附近最近的可见敌对坦克
+func (c *CommandMenu) pick(x, y float64) *Tank {
+	g := c.game
+	var picked *Tank
+	best := float64(PickRadius)
+	Each(g.world, g.world.tanks, func(id Entity, tk *Tank) {
+		if tk.life <= 0 || !g.hostile(g.hero.id, id) || !g.fog.Visible(tk.BoxSprite) {
+			return
+		}
+		tx, ty := g.camera.ToScreen(center(tk.BoxSprite))
+		if dist := math.Hypot(tx-x, ty-y); dist < best {
+			picked, best = tk, dist
+		}
+	})
+	return picked
+}
+
+// center 菜单中心在英雄的屏幕位置
+func (c *CommandMenu) center() (float64, float64) {
+	return c.game.camera.ToScreen(center(c.game.hero.BoxSprite))
+}
+
+func (c *CommandMenu) Draw(screen *ebiten.Image) {
+	if !c.open {
+		return
+	}
+	g := c.game
+	cx, cy := c.center()
+	for i, offset := range CommandOffsets {
+		x, y := float32(cx+offset[0]*CommandRadius), float32(cy+offset[1]*CommandRadius)
+		clr := color.RGBA{A: 0xc0}
+		if i == c.choice {
+			clr = color.RGBA{R: 0xc0, G: 0x60, A: 0xe0}
+		}
+		fillCircle(screen, x, y, 34, clr, true)
+		strokeCircle(screen, x, y, 34, 2, colornames.Limegreen, true)
+		drawText(screen, OrderLabels[i], g.chsFont, int(x)-20, int(y)+7, g.skin.Palette.Text)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"math"
+)
+
+const (
+	CaptureLimit  = 3           // 先夺得这么多次旗帜的一方获胜
+	CapturePoints = 200         // 英雄一方每次夺旗的得分
+	FlagTouch     = 40          // 坦克中心离旗帜多近算碰到
+	FlagReturn    = 600         // 掉落的旗帜无人触碰时自动回到基地的帧数
+	BaseRadius    = 150         // 带着旗帜进入本方基地多近算夺旗成功
+	FlagRoundTime = 8 * 60 * 60 // 每局的帧数，运旗要穿过整张地图，时间更长
+	FlagRespawn   = 300         // 阵亡后等待重生的帧数，较长的等待让夺旗有机会得手
+)
+
+// Flag 阵营的旗帜，放在本方基地，被敌方坦克碰到后被带走，
+// 携带者阵亡时掉落在原地，本方坦克碰到掉落的旗帜时立即送回基地
+type Flag struct {
+	team    Team
+	homeX   float64
+	homeY   float64
+	x       float64
+	y       float64
+	carrier *Tank
+	dropped int // 掉落后剩余的自动返回帧数，0表示不在地上
+}
+
+func (f *Flag) Home() bool {
+	return f.carrier == nil && f.dropped == 0
+}
+
+func (f *Flag) reset() {
+	f.x, f.y = f.homeX, f.homeY
+	f.carrier, f.dropped = nil, 0
+}
+
+// CaptureTheFlag 夺旗模式，把敌方旗帜带回本方基地得分，本方旗帜必须在基地
+type CaptureTheFlag struct {
+	match
+	flags map[Team]*Flag
+}
+
+func NewCaptureTheFlag(g *Game) *CaptureTheFlag {
+	return &CaptureTheFlag{match: match{game: g, roundTime: FlagRoundTime, respawnDelay: FlagRespawn}}
+}
+
+func (c *CaptureTheFlag) Name() string {
+	return "ctf"
+}
+
+func (c *CaptureTheFlag) Reset() {
+	c.match.Reset()
+	c.flags = map[Team]*Flag{}
+	for _, team := range []Team{TeamHero, TeamEnemy} {
+		x, y := c.base(team)
+		c.flags[team] = &Flag{team: team, homeX: x, homeY: y}
+		c.flags[team].reset()
+	}
+}
+
+func (c *CaptureTheFlag) Update() {
+	c.tick()
+	if c.over {
+		return
+	}
+	g := c.game
+	for _, flag := range c.flags {
+		if flag.carrier != nil {
+			if flag.carrier.life <= 0 {
+				flag.carrier, flag.dropped = nil, FlagReturn
+			} else {
+				flag.x, flag.y = center(flag.carrier.BoxSprite)
+			}
+			continue
+		}
+		if flag.dropped > 0 {
+			if flag.dropped--; flag.dropped == 0 {
+				flag.reset()
+			}
+		}
+		Each(g.world, g.world.tanks, func(id Entity, tk *Tank) {
+			if tk.life <= 0 || flag.carrier != nil {
+				return
+			}
+			if x, y := center(tk.BoxSprite); math.Hypot(x-flag.x, y-flag.y) > FlagTouch {
+				return
+			}
+			switch g.world.teams[id] {
+			case flag.team:
+				if flag.dropped > 0 {
+					flag.reset()
+					c.notify(flag.team, "旗帜已送回")
+				}
+			case otherTeam(flag.team):
+				flag.carrier, flag.dropped = tk, 0
+				c.notify(flag.team, "旗帜被夺走")
+			}
+		})
+	}
+	for team, own := range c.flags {
+		flag := c.flags[otherTeam(team)]
+		if flag.carrier == nil || !own.Home() {
+			continue
+		}
+		if x, y := center(flag.carrier.BoxSprite); math.Hypot(x-own.homeX, y-own.homeY) > BaseRadius {
+			continue
+		}
+		flag.reset()
+		c.scores[team]++
+		c.scored(team, CapturePoints)
+		c.notify(team, "夺旗成功")
+		if c.scores[team] >= CaptureLimit {
+			c.finish(team)
+			return
+		}
+	}
+}
+
+// notify 用阵营名称和颜色在英雄上方提示旗帜的变化
+func (c *CaptureTheFlag) notify(team Team, msg string) {
+	c.game.ShowText(TeamLabels[team]+msg, TeamColors[team])
+}
+
+// Goal 携带旗帜时回基地，本方旗帜被夺时追击携带者或去捡回，
+// 队友携带旗帜时护送，否则一部分坦克守家，其余去夺敌方旗帜
+func (c *CaptureTheFlag) Goal(tk *Tank) (float64, float64, bool) {
+	team := c.game.world.teams[tk.id]
+	own, flag := c.flags[team], c.flags[otherTeam(team)]
+	if own == nil {
+		return 0, 0, false
+	}
+	dx, dy := goalOffset(tk, 60)
+	switch {
+	case flag.carrier == tk:
+		return own.homeX, own.homeY, true
+	case !own.Home():
+		return own.x, own.y, true
+	case flag.carrier != nil:
+		x, y := center(flag.carrier.BoxSprite)
+		return x + dx, y + dy, true
+	case tk.id%3 == 0:
+		return own.homeX + dx*2, own.homeY + dy*2, true
+	}
+	return flag.x, flag.y, true
+}
+
+// DrawWorld 画出基地和旗帜，旗帜总是可见
+func (c *CaptureTheFlag) DrawWorld(screen *ebiten.Image) {
+	c.drawBases(screen)
+	cam := c.game.camera
+	for _, flag := range c.flags {
+		x, y := cam.ToScreen(flag.x, flag.y)
+		if flag.carrier != nil {
+			y -= 30 // 画在携带者上方
+		}
+		sx, sy := float32(x), float32(y)
+		strokeLine(screen, sx, sy+16, sx, sy-24, 3, TeamColors[flag.team], true)
+		var path vector.Path
+		path.MoveTo(sx, sy-24)
+		path.LineTo(sx+24, sy-16)
+		path.LineTo(sx, sy-8)
+		path.Close()
+		vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+		clr := TeamColors[flag.team]
+		for i := range vertices {
+			vertices[i].ColorR = float32(clr.R) / 0xff
+			vertices[i].ColorG = float32(clr.G) / 0xff
+			vertices[i].ColorB = float32(clr.B) / 0xff
+			vertices[i].ColorA = 1
+		}
+		drawTriangles(screen, vertices, indices, whiteImage, &ebiten.DrawTrianglesOptions{})
+	}
+}
+
+func (c *CaptureTheFlag) DrawHUD(screen *ebiten.Image) {
+	c.drawHUD(screen, fmt.Sprint(c.scores[TeamHero]), fmt.Sprint(c.scores[TeamEnemy]))
+}
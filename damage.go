@@ -0,0 +1,137 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+const (
+	SideHitScale = 1.5 // 击中侧面的伤害倍率
+	RearHitScale = 2   // 击中尾部的伤害倍率
+	CritChance   = 0.1 // 暴击概率
+	CritScale    = 1.5 // 暴击的伤害倍率
+	CritStun     = 90  // 暴击后无法移动或射击的帧数
+	FloatLife    = 50  // 伤害数字显示的帧数
+	MinDamage    = 0.1 // 护甲再高也至少造成的伤害
+	LifeBarH     = 5   // 血条高度
+)
+
+// FloatText 飘起并淡出的伤害数字
+type FloatText struct {
+	X     float64
+	Y     float64
+	Text  string
+	Color color.RGBA
+	Life  int
+}
+
+// TakeDamage 按武器伤害、击中方式、护甲和暴击计算伤害
+func (h *Health) TakeDamage(b *Bullet) (damage float64, crit bool) {
+	crit = rand.Float64() < CritChance
+	damage = b.damage * (1 - h.armor)
+	if crit {
+		damage *= CritScale
+	}
+	if h.onHit != nil {
+		damage *= h.onHit(b, crit)
+	}
+	return math.Max(damage, MinDamage), crit
+}
+
+// hitEffect 坦克按击中方向修正伤害，暴击时随机禁止移动或射击
+func (tk *Tank) hitEffect(b *Bullet, crit bool) float64 {
+	if crit {
+		if rand.Intn(2) == 0 {
+			tk.moveStun = CritStun
+		} else {
+			tk.fireStun = CritStun
+		}
+	}
+	return tk.hitDirectionScale(b)
+}
+
+// hitDirectionScale 比较子弹飞行方向和车头朝向，从后方和侧面击中伤害更高
+func (tk *Tank) hitDirectionScale(b *Bullet) float64 {
+	// 坦克角度为Pi时车头向上，子弹角度为0时向上飞行
+	hullSin, hullCos := math.Sincos(tk.A)
+	bulletSin, bulletCos := math.Sincos(b.A)
+	dot := -hullSin*bulletSin - hullCos*bulletCos
+	if dot > 0.5 {
+		return RearHitScale
+	}
+	if dot > -0.5 {
+		return SideHitScale
+	}
+	return 1
+}
+
+// tickStun 无法移动的暴击效果逐帧恢复，无法射击的由武器系统恢复
+func (tk *Tank) tickStun() {
+	if tk.moveStun > 0 {
+		tk.moveStun--
+	}
+}
+
+// ShowDamage 在坦克上方显示伤害数字
+func (g *Game) ShowDamage(tk *Tank, damage float64, crit bool) {
+	w, _ := tk.GetDrawWH()
+	clr := colornames.White
+	label := strconv.FormatFloat(damage, 'f', 1, 64)
+	if crit {
+		clr = colornames.Orange
+		label += "!"
+	}
+	g.floatTexts = append(g.floatTexts, FloatText{
+		X: tk.X + w/2 - float64(len(label))*5 + (rand.Float64()-0.5)*20,
+		Y: tk.Y, Text: label, Color: clr, Life: FloatLife,
+	})
+}
+
+// ShowText 在英雄上方显示一条提示
+func (g *Game) ShowText(label string, clr color.RGBA) {
+	w, _ := g.hero.GetDrawWH()
+	g.floatTexts = append(g.floatTexts, FloatText{
+		X: g.hero.X + w/2 - float64(len([]rune(label)))*10, Y: g.hero.Y - 10,
+		Text: label, Color: clr, Life: FloatLife,
+	})
+}
+
+func (g *Game) updateFloatTexts() {
+	alive := g.floatTexts[:0]
+	for _, ft := range g.floatTexts {
+		ft.Life--
+		ft.Y -= 1
+		if ft.Life > 0 {
+			alive = append(alive, ft)
+		}
+	}
+	g.floatTexts = alive
+}
+
+func (g *Game) drawFloatTexts(screen *ebiten.Image) {
+	for _, ft := range g.floatTexts {
+		if !g.fog.Visible(&BoxSprite{X: ft.X, Y: ft.Y}) {
+			continue
+		}
+		x, y := g.camera.ToScreen(ft.X, ft.Y)
+		fade := float64(ft.Life) / FloatLife
+		clr := color.RGBA{R: uint8(float64(ft.Color.R) * fade), G: uint8(float64(ft.Color.G) * fade),
+			B: uint8(float64(ft.Color.B) * fade), A: uint8(float64(ft.Color.A) * fade)}
+		drawText(screen, ft.Text, g.chsFont, int(x), int(y), clr)
+	}
+}
+
+// DrawLifeBar 在坦克下方绘制血条，颜色随剩余血量变化
+func (tk *Tank) DrawLifeBar(screen *ebiten.Image) {
+	w, h := tk.GetDrawWH()
+	x, y := tk.game.camera.ToScreen(tk.X, tk.Y+h+2)
+	ratio := math.Max(0, math.Min(1, tk.life/tk.maxLife))
+	colors := tk.game.skin.Palette.Life
+	index := max(0, int(math.Ceil(ratio*float64(len(colors))))-1)
+	fillRect(screen, float32(x), float32(y), float32(w), LifeBarH, color.RGBA{A: 0x80}, false)
+	fillRect(screen, float32(x), float32(y), float32(w*ratio), LifeBarH, colors[index], false)
+}
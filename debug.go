@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+	"image/color"
+	"time"
+)
+
+const BenchFrames = 240 // 渲染基准测试每种绘制方式持续的帧数
+
+// drawCalls 本帧提交的绘制次数，所有绘制都经过下面的函数计数
+var drawCalls int
+
+func drawImage(dst, src *ebiten.Image, options *ebiten.DrawImageOptions) {
+	dst.DrawImage(src, options)
+	drawCalls++
+}
+
+func drawTriangles(dst *ebiten.Image, vertices []ebiten.Vertex, indices []uint16, src *ebiten.Image,
+	options *ebiten.DrawTrianglesOptions) {
+	dst.DrawTriangles(vertices, indices, src, options)
+	drawCalls++
+}
+
+func drawText(dst *ebiten.Image, str string, face font.Face, x, y int, clr color.Color) {
+	text.Draw(dst, str, face, x, y, clr)
+	drawCalls++
+}
+
+func fillRect(dst *ebiten.Image, x, y, w, h float32, clr color.Color, antialias bool) {
+	vector.DrawFilledRect(dst, x, y, w, h, clr, antialias)
+	drawCalls++
+}
+
+func strokeRect(dst *ebiten.Image, x, y, w, h, width float32, clr color.Color, antialias bool) {
+	vector.StrokeRect(dst, x, y, w, h, width, clr, antialias)
+	drawCalls++
+}
+
+func fillCircle(dst *ebiten.Image, cx, cy, r float32, clr color.Color, antialias bool) {
+	vector.DrawFilledCircle(dst, cx, cy, r, clr, antialias)
+	drawCalls++
+}
+
+func strokeCircle(dst *ebiten.Image, cx, cy, r, width float32, clr color.Color, antialias bool) {
+	vector.StrokeCircle(dst, cx, cy, r, width, clr, antialias)
+	drawCalls++
+}
+
+func strokeLine(dst *ebiten.Image, x0, y0, x1, y1, width float32, clr color.Color, antialias bool) {
+	vector.StrokeLine(dst, x0, y0, x1, y1, width, clr, antialias)
+	drawCalls++
+}
+
+// BenchMode 渲染基准测试当前的绘制方式
+type BenchMode int
+
+const (
+	BenchOff    BenchMode = iota
+	BenchTiles            // 每帧逐块绘制地面
+	BenchCached           // 每帧绘制缓存层
+)
+
+// Debug 调试信息层，F3键开关，F4键运行地面渲染的基准测试
+type Debug struct {
+	game       *Game
+	visible    bool
+	drawCalls  int // 上一帧的绘制次数
+	benchMode  BenchMode
+	benchFrame int
+	benchStart time.Time
+	benchTimes [BenchCached + 1]time.Duration
+	benchVsync bool // 测试前是否开启垂直同步
+	bench      string
+}
+
+func NewDebug(g *Game) *Debug {
+	return &Debug{game: g}
+}
+
+func (d *Debug) Update() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		d.visible = !d.visible
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) && d.benchMode == BenchOff {
+		// 关闭垂直同步，帧率不受刷新率限制，帧间隔包含GPU完成上一帧的时间
+		d.benchVsync = ebiten.IsVsyncEnabled()
+		ebiten.SetVsyncEnabled(false)
+		d.benchMode, d.benchFrame, d.benchStart = BenchTiles, 0, time.Now()
+		d.bench = "地面基准测试中……"
+		d.visible = true
+	}
+}
+
+// BeginFrame 记录上一帧的绘制次数并重新计数，基准测试时按帧推进
+func (d *Debug) BeginFrame() {
+	d.drawCalls = drawCalls
+	drawCalls = 0
+	if d.benchMode == BenchOff {
+		return
+	}
+	if d.benchFrame++; d.benchFrame < BenchFrames {
+		return
+	}
+	d.benchTimes[d.benchMode] = time.Since(d.benchStart) / BenchFrames
+	d.benchFrame, d.benchStart = 0, time.Now()
+	if d.benchMode++; d.benchMode > BenchCached {
+		d.benchMode = BenchOff
+		ebiten.SetVsyncEnabled(d.benchVsync)
+		d.bench = fmt.Sprintf("地面每帧：逐块%v 缓存%v", d.benchTimes[BenchTiles].Round(time.Microsecond),
+			d.benchTimes[BenchCached].Round(time.Microsecond))
+	}
+}
+
+// Bench 当前的基准测试方式
+func (d *Debug) Bench() BenchMode {
+	return d.benchMode
+}
+
+func (d *Debug) Draw(screen *ebiten.Image) {
+	if !d.visible {
+		return
+	}
+	g := d.game
+	lines := []string{
+		fmt.Sprintf("绘制次数：%d", d.drawCalls),
+		fmt.Sprintf("TPS：%.0f  FPS：%.0f", ebiten.ActualTPS(), ebiten.ActualFPS()),
+		fmt.Sprintf("粒子：%d", len(g.particles.items)),
+		fmt.Sprintf("镜头：%.0f, %.0f", g.camera.X, g.camera.Y),
+	}
+	if d.bench != "" {
+		lines = append(lines, d.bench)
+	}
+	for i, line := range lines {
+		drawText(screen, line, g.chsFont, 3, g.height-10-(len(lines)-1-i)*23, colornames.Lightyellow)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"math"
+	"math/rand"
+)
+
+const (
+	DecalFadeRate  = 60        // 每隔几帧淡化一次贴花层
+	DecalFadeAlpha = 0.9       // 每次淡化保留的不透明度
+	DecalFadeStep  = 2.0 / 255 // 每次淡化再减去的不透明度，只按比例淡化时低透明度会因取整停住不变
+	TrackAlpha     = 0.35      // 履带印的不透明度
+)
+
+// Decals 持久的地面贴花层，履带印和爆炸痕迹烘焙到一张世界大小的图片上，
+// 定期整体淡化，所以无论留下多少痕迹绘制开销都不变；贴花层单独合成在地形层和障碍物层之间，
+// 烘焙和淡化都不需要重绘地面缓存
+type Decals struct {
+	game  *Game
+	layer *ebiten.Image
+	back  *ebiten.Image // 淡化时交替使用的图片
+	oil   [2]SpriteInfo
+	smoke SpriteInfo
+}
+
+func NewDecals(g *Game, bus *EventBus) *Decals {
+	d := &Decals{
+		game:  g,
+		layer: ebiten.NewImage(g.worldWidth, g.worldHeight),
+		back:  ebiten.NewImage(g.worldWidth, g.worldHeight),
+		oil:   [2]SpriteInfo{g.sprite("oilSpill_large"), g.sprite("oilSpill_small")},
+		smoke: g.sprite("explosionSmoke3"),
+	}
+	Subscribe(bus, func(e TankDestroyed) {
+		d.StampExplosion(e.Tank)
+	})
+	Subscribe(bus, func(Restarted) {
+		d.Clear()
+	})
+	return d
+}
+
+func (d *Decals) Clear() {
+	d.layer.Clear()
+}
+
+// Stamp 把精灵以旋转中心(x, y)烘焙到贴花层
+func (d *Decals) Stamp(info SpriteInfo, x, y, angle, scale float64, colorScale ebiten.ColorScale) {
+	w, h := info.Size()
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(-float64(w)*info.PivotX, -float64(h)*info.PivotY)
+	options.GeoM.Scale(scale, scale)
+	options.GeoM.Rotate(angle)
+	options.GeoM.Translate(x, y)
+	options.ColorScale = colorScale
+	drawImage(d.layer, d.game.atlas.Image(info), options)
+}
+
+// StampTrack 坦克每移动一段履带的长度留下一个履带印
+func (d *Decals) StampTrack(tk *Tank, dist float64) {
+	info := tk.tracks.Frame()
+	infoW, infoH := info.Size()
+	scale := tk.W * 0.9 / float64(infoW)
+	tk.trackDist += dist
+	if tk.trackDist < float64(infoH)*scale {
+		return
+	}
+	tk.trackDist = 0
+	w, h := tk.GetDrawWH()
+	var colorScale ebiten.ColorScale
+	colorScale.ScaleAlpha(TrackAlpha)
+	d.Stamp(info, tk.X+w/2, tk.Y+h/2, tk.A, scale, colorScale)
+	tk.tracks.Update()
+}
+
+// StampExplosion 坦克爆炸处留下焦痕和油污
+func (d *Decals) StampExplosion(tk *Tank) {
+	w, h := tk.GetDrawWH()
+	x, y := tk.X+w/2, tk.Y+h/2
+	var scorch ebiten.ColorScale
+	scorch.Scale(0.1, 0.08, 0.05, 0.6)
+	smokeW, _ := d.smoke.Size()
+	d.Stamp(d.smoke, x, y, rand.Float64()*math.Pi*2, w/float64(smokeW), scorch)
+	d.Stamp(d.oil[0], x, y, rand.Float64()*math.Pi*2, 0.8, ebiten.ColorScale{})
+	for i := 0; i < 3; i++ {
+		d.Stamp(d.oil[1], x+(rand.Float64()-0.5)*w, y+(rand.Float64()-0.5)*h,
+			rand.Float64()*math.Pi*2, 1, ebiten.ColorScale{})
+	}
+}
+
+// Update 定期把整层淡化一次，按比例淡化后再减去固定的量，旧的痕迹最终完全消失
+func (d *Decals) Update() {
+	if d.game.updates%DecalFadeRate != 0 {
+		return
+	}
+	d.back.Clear()
+	options := &ebiten.DrawImageOptions{}
+	options.ColorScale.ScaleAlpha(DecalFadeAlpha)
+	drawImage(d.back, d.layer, options)
+	// 预乘透明度的颜色和透明度减去同样的量，结果在0截断
+	step := &ebiten.DrawImageOptions{Blend: ebiten.Blend{
+		BlendFactorSourceRGB:        ebiten.BlendFactorOne,
+		BlendFactorSourceAlpha:      ebiten.BlendFactorOne,
+		BlendFactorDestinationRGB:   ebiten.BlendFactorOne,
+		BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+		BlendOperationRGB:           ebiten.BlendOperationReverseSubtract,
+		BlendOperationAlpha:         ebiten.BlendOperationReverseSubtract,
+	}}
+	step.GeoM.Scale(float64(d.game.worldWidth), float64(d.game.worldHeight))
+	step.ColorScale.Scale(DecalFadeStep, DecalFadeStep, DecalFadeStep, DecalFadeStep)
+	drawImage(d.back, whiteImage, step)
+	d.layer, d.back = d.back, d.layer
+}
+
+// Draw 把贴花层按镜头绘制到屏幕
+func (d *Decals) Draw(screen *ebiten.Image) {
+	options := &ebiten.DrawImageOptions{}
+	d.game.camera.Apply(&options.GeoM)
+	drawImage(screen, d.layer, options)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Entity 实体的编号，从1开始，0表示没有实体
+type Entity int
+
+// Team 实体所属的阵营，子弹继承发射者的阵营
+type Team int
+
+const (
+	TeamNone    Team = iota // 障碍物等不属于任何阵营
+	TeamNeutral             // 中立，默认不与任何阵营敌对
+	TeamHero
+	TeamEnemy // 混战时之后的编号依次分配给每辆AI坦克
+)
+
+const (
+	LayerBullet = iota // 子弹画在坦克下面
+	LayerTank
+	LayerCount
+)
+
+// Sprite 绘制组件，按层从低到高绘制
+type Sprite struct {
+	Layer int
+	Draw  func(screen *ebiten.Image)
+}
+
+// Collider 碰撞组件，位置和尺寸取自实体的Transform
+type Collider struct {
+	Solid  bool    // 阻挡坦克移动
+	Static bool    // 障碍物，画在地面缓存上并遮挡视线
+	Metal  bool    // 反弹子弹，不能被穿透
+	Resist float64 // 子弹穿透时损失的伤害
+}
+
+// Health 生命组件，带碰撞组件的实体有了它就能被子弹击中，死亡时播放爆炸动画
+type Health struct {
+	life       float64
+	maxLife    float64
+	armor      float64                            // 护甲，按比例减少受到的伤害
+	hitStatus  int                                // 大于0表示被击中，免疫攻击
+	hitProtect int                                // 击中后的免疫时间
+	dying      *AnimatedSprite                    // 爆炸动画，被击中且死亡时播放
+	onHit      func(b *Bullet, crit bool) float64 // 按击中方式返回伤害倍率，可以为空
+	onDeath    func()                             // 爆炸动画播完后调用
+}
+
+// die 在实体中心播放爆炸动画，播完后调用onDeath
+func (h *Health) die(g *Game, box *BoxSprite) {
+	x, y := center(box)
+	anim := g.animation("explosion").Play(func() {
+		h.dying = nil
+		if h.onDeath != nil {
+			h.onDeath()
+		}
+	})
+	h.dying = NewAnimatedSprite(g.atlas, anim, x, y, 1)
+}
+
+// Gun 武器组件
+type Gun struct {
+	weapon        Weapon
+	bulletSize    float64
+	bulletSpeed   float64
+	shootCool     int // 射击冷却程度
+	shootCoolDown int // 射击冷却速度
+	fireStun      int // 大于0表示被暴击后无法射击
+}
+
+// Ready 冷却完成且没有被暴击禁止射击
+func (gun *Gun) Ready() bool {
+	return gun.fireStun == 0 && gun.shootCool >= ShootCooled
+}
+
+// Controller AI组件，玩家的输入也作为一种控制器
+type Controller interface {
+	Update()
+}
+
+// Store 一种组件的存储
+type Store[T any] map[Entity]T
+
+func (s Store[T]) remove(id Entity) {
+	delete(s, id)
+}
+
+// World 实体和组件的存储，系统按创建顺序遍历拥有某种组件的实体，
+// 移除的实体在帧末统一删除，遍历期间移除是安全的
+type World struct {
+	next       Entity
+	entities   []Entity
+	removed    map[Entity]bool
+	stores     []interface{ remove(Entity) }
+	transforms Store[*BoxSprite]
+	sprites    Store[Sprite]
+	colliders  Store[*Collider]
+	healths    Store[*Health]
+	weapons    Store[*Gun]
+	ais        Store[Controller]
+	teams      Store[Team]
+	tanks      Store[*Tank]
+	bullets    Store[*Bullet]
+}
+
+func NewWorld() *World {
+	w := &World{
+		removed:    map[Entity]bool{},
+		transforms: Store[*BoxSprite]{},
+		sprites:    Store[Sprite]{},
+		colliders:  Store[*Collider]{},
+		healths:    Store[*Health]{},
+		weapons:    Store[*Gun]{},
+		ais:        Store[Controller]{},
+		teams:      Store[Team]{},
+		tanks:      Store[*Tank]{},
+		bullets:    Store[*Bullet]{},
+	}
+	w.stores = []interface{ remove(Entity) }{w.transforms, w.sprites, w.colliders,
+		w.healths, w.weapons, w.ais, w.teams, w.tanks, w.bullets}
+	return w
+}
+
+// Create 创建一个没有组件的实体
+func (w *World) Create() Entity {
+	w.next++
+	w.entities = append(w.entities, w.next)
+	return w.next
+}
+
+// Remove 标记实体在帧末移除，之后的遍历会跳过它
+func (w *World) Remove(id Entity) {
+	w.removed[id] = true
+}
+
+// Flush 删除标记移除的实体和它们的组件
+func (w *World) Flush() {
+	if len(w.removed) == 0 {
+		return
+	}
+	entities := w.entities[:0]
+	for _, id := range w.entities {
+		if !w.removed[id] {
+			entities = append(entities, id)
+			continue
+		}
+		for _, store := range w.stores {
+			store.remove(id)
+		}
+	}
+	w.entities = entities
+	clear(w.removed)
+}
+
+// Each 按创建顺序遍历拥有组件的实体，遍历期间创建的实体下次才会被遍历
+func Each[T any](w *World, store Store[T], fn func(Entity, T)) {
+	for _, id := range w.entities {
+		if v, ok := store[id]; ok && !w.removed[id] {
+			fn(id, v)
+		}
+	}
+}
+
+// Find 按创建顺序返回第一个满足条件的实体，没有则返回0
+func Find[T any](w *World, store Store[T], fn func(Entity, T) bool) Entity {
+	for _, id := range w.entities {
+		if v, ok := store[id]; ok && !w.removed[id] && fn(id, v) {
+			return id
+		}
+	}
+	return 0
+}
+
+// Blocking 实体是否阻挡坦克移动，死亡和等待出生的坦克不阻挡
+func (w *World) Blocking(id Entity) bool {
+	collider, ok := w.colliders[id]
+	if !ok || !collider.Solid {
+		return false
+	}
+	health, ok := w.healths[id]
+	return !ok || health.life > 0
+}
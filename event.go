@@ -0,0 +1,85 @@
+package main
+
+import "reflect"
+
+// BulletFired 坦克开火
+type BulletFired struct {
+	Tank   *Tank
+	Bullet *Bullet
+}
+
+// TankHit 坦克被子弹击中并受到伤害
+type TankHit struct {
+	Tank   *Tank
+	Bullet *Bullet
+	Damage float64
+	Crit   bool
+}
+
+// TankDestroyed 坦克被击毁
+type TankDestroyed struct {
+	Tank   *Tank
+	Bullet *Bullet
+}
+
+// HeroDied 英雄的死亡动画结束
+type HeroDied struct {
+	Hero *Hero
+}
+
+// WaveCleared 一波敌人被消灭
+type WaveCleared struct {
+	Wave int
+}
+
+// BulletIntercepted 子弹与敌方子弹相撞，两发子弹都消失
+type BulletIntercepted struct {
+	Bullet *Bullet
+	Other  *Bullet
+}
+
+// ObjectiveScored 阵营完成了游戏目标，例如夺旗成功或占领山头
+type ObjectiveScored struct {
+	Team   Team
+	Points int
+}
+
+// Restarted 开始新的一局
+type Restarted struct{}
+
+// EventBus 按事件类型分发的事件总线，订阅者按注册顺序被调用
+type EventBus struct {
+	handlers map[reflect.Type][]func(any)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: map[reflect.Type][]func(any){}}
+}
+
+// Subscribe 订阅一种事件
+func Subscribe[E any](bus *EventBus, handler func(E)) {
+	key := reflect.TypeOf((*E)(nil)).Elem()
+	bus.handlers[key] = append(bus.handlers[key], func(event any) {
+		handler(event.(E))
+	})
+}
+
+// Emit 把事件同步分发给所有订阅者
+func Emit[E any](bus *EventBus, event E) {
+	for _, handler := range bus.handlers[reflect.TypeOf((*E)(nil)).Elem()] {
+		handler(event)
+	}
+}
+
+// subscribeFeedback 镜头震动和伤害数字订阅游戏事件
+func (g *Game) subscribeFeedback(bus *EventBus) {
+	Subscribe(bus, func(e TankHit) {
+		g.ShowDamage(e.Tank, e.Damage, e.Crit)
+	})
+	Subscribe(bus, func(e TankDestroyed) {
+		g.camera.Shake(ExplodeShake)
+	})
+	Subscribe(bus, func(Restarted) {
+		g.floatTexts = g.floatTexts[:0]
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"math"
+)
+
+const (
+	FogCell       = 20   // 迷雾格子的边长，绘制时放大并线性过滤得到柔和边缘
+	FogDark       = 0.88 // 未探索区域的遮罩浓度
+	FogExplored   = 0.55 // 已探索但不在视野内的遮罩浓度
+	FogSoftEdge   = 0.25 // 视野边缘渐变部分占视野半径的比例
+	FogUpdateRate = 3    // 每隔几帧重新计算一次迷雾
+)
+
+// Fog 战争迷雾，只显示英雄视野内的敌人，已探索区域保持昏暗可见
+type Fog struct {
+	game     *Game
+	enabled  bool
+	cols     int
+	rows     int
+	explored []bool
+	pixels   []byte
+	image    *ebiten.Image
+}
+
+func NewFog(g *Game) *Fog {
+	f := &Fog{
+		game: g,
+		cols: (g.worldWidth + FogCell - 1) / FogCell,
+		rows: (g.worldHeight + FogCell - 1) / FogCell,
+	}
+	f.explored = make([]bool, f.cols*f.rows)
+	f.pixels = make([]byte, f.cols*f.rows*4)
+	f.image = ebiten.NewImage(f.cols, f.rows)
+	return f
+}
+
+// Reset 重开时清除已探索的区域
+func (f *Fog) Reset() {
+	for i := range f.explored {
+		f.explored[i] = false
+	}
+	if f.enabled {
+		f.refresh()
+	}
+}
+
+// Update F键开关迷雾
+func (f *Fog) Update() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		f.enabled = !f.enabled
+		if f.enabled {
+			f.refresh()
+		}
+	}
+	if f.enabled && !f.game.pause && f.game.updates%FogUpdateRate == 0 {
+		f.refresh()
+	}
+}
+
+// Visible 精灵是否能被玩家看到，关闭迷雾时总是可见
+func (f *Fog) Visible(s *BoxSprite) bool {
+	if !f.enabled || f.game.hero.life <= 0 {
+		return true
+	}
+	return f.game.hero.CanSee(s)
+}
+
+// refresh 重新计算每个格子的遮罩浓度
+func (f *Fog) refresh() {
+	hero := f.game.hero
+	w, h := hero.GetDrawWH()
+	eye := &BoxSprite{W: FogCell, H: FogCell}
+	cx, cy := hero.X+w/2, hero.Y+h/2
+	for row := 0; row < f.rows; row++ {
+		for col := 0; col < f.cols; col++ {
+			i := row*f.cols + col
+			alpha := FogDark
+			if f.explored[i] {
+				alpha = FogExplored
+			}
+			eye.X, eye.Y = float64(col*FogCell), float64(row*FogCell)
+			dist := math.Hypot(eye.X+FogCell/2-cx, eye.Y+FogCell/2-cy)
+			if dist <= hero.vision && f.game.InSight(hero.BoxSprite, eye) {
+				f.explored[i] = true
+				// 视野边缘渐变到已探索的浓度
+				edge := (dist/hero.vision - (1 - FogSoftEdge)) / FogSoftEdge
+				alpha = FogExplored * math.Max(0, edge)
+			}
+			f.pixels[i*4+3] = byte(alpha * 0xff)
+		}
+	}
+	f.image.WritePixels(f.pixels)
+}
+
+func (f *Fog) Draw(screen *ebiten.Image) {
+	if !f.enabled {
+		return
+	}
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Scale(FogCell, FogCell)
+	f.game.camera.Apply(&options.GeoM)
+	options.Filter = ebiten.FilterLinear
+	drawImage(screen, f.image, options)
+}
+
+// CanSee 目标是否在视野半径内且视线未被遮挡，AI和玩家使用相同的规则
+func (tk *Tank) CanSee(s *BoxSprite) bool {
+	w1, h1 := tk.GetDrawWH()
+	w2, h2 := s.GetDrawWH()
+	dist := math.Hypot(tk.X+w1/2-s.X-w2/2, tk.Y+h1/2-s.Y-h2/2)
+	return dist <= tk.vision && tk.game.InSight(tk.BoxSprite, s)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+)
+
+// Ground 地面，地形和树等静态内容缓存在两张世界大小的图片上，贴花层夹在两者之间，
+// 只有内容变化的区域才重绘，每帧只需三次绘制
+type Ground struct {
+	game   *Game
+	cols   int
+	rows   int
+	tiles  []*Terrain        // 每个格子的地形
+	layer  *ebiten.Image     // 地形层
+	top    *ebiten.Image     // 障碍物层，画在贴花上面
+	dirty  []image.Rectangle // 待重绘的区域
+	origin *Camera           // 不偏移的镜头，用于把世界坐标直接绘制到缓存
+}
+
+// Invalidate 标记区域需要重绘，例如障碍物被摧毁
+func (g *Ground) Invalidate(rect image.Rectangle) {
+	rect = rect.Intersect(g.layer.Bounds())
+	if rect.Empty() {
+		return
+	}
+	for i, dirty := range g.dirty {
+		if dirty.Overlaps(rect) {
+			g.dirty[i] = dirty.Union(rect)
+			return
+		}
+	}
+	g.dirty = append(g.dirty, rect)
+}
+
+// render 重绘缓存中的一块区域，绘制会被裁剪到区域内
+func (g *Ground) render(rect image.Rectangle) {
+	dst := g.layer.SubImage(rect).(*ebiten.Image)
+	dst.Clear()
+	g.drawTiles(dst, g.origin, float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Max.X), float64(rect.Max.Y))
+
+	top := g.top.SubImage(rect).(*ebiten.Image)
+	top.Clear()
+	g.game.eachObstacle(func(_ Entity, obstacle *BoxSprite) {
+		w, h := obstacle.GetDrawWH()
+		box := image.Rect(int(obstacle.X), int(obstacle.Y), int(obstacle.X+w)+1, int(obstacle.Y+h)+1)
+		if box.Overlaps(rect) {
+			obstacle.Draw(top, g.origin)
+		}
+	})
+}
+
+// drawTiles 绘制与区域相交的地形格子
+func (g *Ground) drawTiles(dst *ebiten.Image, cam *Camera, x0, y0, x1, y1 float64) {
+	for row := max(int(y0)/TileSize, 0); row < g.rows && row*TileSize < int(y1); row++ {
+		for col := max(int(x0)/TileSize, 0); col < g.cols && col*TileSize < int(x1); col++ {
+			terrain := g.tiles[row*g.cols+col]
+			g.drawTile(dst, cam, g.game.sprite(terrain.Sprite), col, row)
+			if terrain.Overlay != "" {
+				g.drawTile(dst, cam, g.game.sprite(terrain.Overlay), col, row)
+			}
+		}
+	}
+}
+
+// drawTile 把贴图绘制在格子中央
+func (g *Ground) drawTile(dst *ebiten.Image, cam *Camera, info SpriteInfo, col, row int) {
+	w, h := info.Size()
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(float64(col*TileSize+(TileSize-w)/2), float64(row*TileSize+(TileSize-h)/2))
+	cam.Apply(&options.GeoM)
+	options.ColorScale.SetG(0.9)
+	drawImage(dst, g.game.atlas.Image(info), options)
+}
+
+// Draw 依次绘制地形层、贴花层和障碍物层，基准测试时可以改为逐块绘制
+func (g *Ground) Draw(screen *ebiten.Image) {
+	cam := g.game.camera
+	if g.game.debug.Bench() == BenchTiles {
+		g.drawTiles(screen, cam, cam.X, cam.Y, cam.X+cam.W, cam.Y+cam.H)
+		g.game.decals.Draw(screen)
+		g.game.eachObstacle(func(_ Entity, obstacle *BoxSprite) {
+			obstacle.Draw(screen, cam)
+		})
+		return
+	}
+	for _, rect := range g.dirty {
+		g.render(rect)
+	}
+	g.dirty = g.dirty[:0]
+
+	options := &ebiten.DrawImageOptions{}
+	cam.Apply(&options.GeoM)
+	drawImage(screen, g.layer, options)
+	g.game.decals.Draw(screen)
+	drawImage(screen, g.top, options)
+}
+
+func (g *Game) initGround() {
+	g.ground = &Ground{
+		game:   g,
+		layer:  ebiten.NewImage(g.worldWidth, g.worldHeight),
+		top:    ebiten.NewImage(g.worldWidth, g.worldHeight),
+		origin: NewCamera(0, 0, 0, 0),
+	}
+	g.ground.initTerrain(g.level)
+
+	info1 := g.sprite("treeGreen_large")
+	info2 := g.sprite("treeBrown_large")
+	for i := 0; i < len(GroundTrees); i++ {
+		info := info1
+		if i == 0 || i == 1 || i == 4 {
+			info = info2
+		}
+		g.ground.addObstacle(info, GroundTrees[i], 1, false, TreeResist)
+	}
+	metal1 := g.sprite("crateMetal")
+	metal2 := g.sprite("barricadeMetal")
+	for i := 0; i < len(GroundMetals); i++ {
+		info := metal1
+		if i%2 == 1 {
+			info = metal2
+		}
+		g.ground.addObstacle(info, GroundMetals[i], 1.5, true, 0)
+	}
+	g.ground.Invalidate(g.ground.layer.Bounds())
+}
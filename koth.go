@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+)
+
+const (
+	HillRadius    = 180         // 山头区域的半径
+	HoldTime      = 60 * 60     // 累计占领这么多帧的一方获胜
+	HillPoints    = 5           // 英雄一方每秒占领的得分
+	HillRoundTime = 5 * 60 * 60 // 每局的帧数
+	HillRespawn   = 120         // 阵亡后等待重生的帧数，较短的等待让争夺持续
+)
+
+// KingOfTheHill 占山为王模式，区域内只有一方活着的坦克时这一方占领，
+// 累计占领时间先满的一方获胜，双方都在区域内时为争夺状态，计时暂停
+type KingOfTheHill struct {
+	match
+	holder    Team // 当前占领的阵营，TeamNone表示无人或争夺中
+	contested bool
+}
+
+func NewKingOfTheHill(g *Game) *KingOfTheHill {
+	return &KingOfTheHill{match: match{game: g, roundTime: HillRoundTime, respawnDelay: HillRespawn}}
+}
+
+func (k *KingOfTheHill) Name() string {
+	return "koth"
+}
+
+func (k *KingOfTheHill) Reset() {
+	k.match.Reset()
+	k.holder, k.contested = TeamNone, false
+}
+
+// hill 山头区域中心的世界坐标
+func (k *KingOfTheHill) hill() (float64, float64) {
+	return float64(k.game.worldWidth) / 2, float64(k.game.worldHeight) / 2
+}
+
+func (k *KingOfTheHill) Update() {
+	k.tick()
+	if k.over {
+		return
+	}
+	g := k.game
+	hx, hy := k.hill()
+	inside := map[Team]bool{}
+	Each(g.world, g.world.tanks, func(id Entity, tk *Tank) {
+		if x, y := center(tk.BoxSprite); tk.life > 0 && math.Hypot(x-hx, y-hy) < HillRadius {
+			inside[g.world.teams[id]] = true
+		}
+	})
+	holder := TeamNone
+	k.contested = inside[TeamHero] && inside[TeamEnemy]
+	if !k.contested && inside[TeamHero] {
+		holder = TeamHero
+	} else if !k.contested && inside[TeamEnemy] {
+		holder = TeamEnemy
+	}
+	if holder != k.holder && holder != TeamNone {
+		g.ShowText(TeamLabels[holder]+"占领山头", TeamColors[holder])
+	}
+	k.holder = holder
+	if holder == TeamNone {
+		return
+	}
+	k.scores[holder]++
+	if k.scores[holder]%60 == 0 {
+		k.scored(holder, HillPoints)
+	}
+	if k.scores[holder] >= HoldTime {
+		k.finish(holder)
+	}
+}
+
+// Goal 所有坦克都去抢占山头，分散在区域内
+func (k *KingOfTheHill) Goal(tk *Tank) (float64, float64, bool) {
+	hx, hy := k.hill()
+	dx, dy := goalOffset(tk, HillRadius/2)
+	return hx + dx, hy + dy, true
+}
+
+// DrawWorld 用占领方的颜色画出山头区域，争夺时闪烁
+func (k *KingOfTheHill) DrawWorld(screen *ebiten.Image) {
+	k.drawBases(screen)
+	x, y := k.game.camera.ToScreen(k.hill())
+	clr := color.RGBA(colornames.Gray)
+	if k.holder != TeamNone {
+		clr = TeamColors[k.holder]
+	}
+	if k.contested && k.game.updates/15%2 == 0 {
+		clr = colornames.Yellow
+	}
+	fill := clr
+	fill.R, fill.G, fill.B, fill.A = fill.R/4, fill.G/4, fill.B/4, 0x40
+	fillCircle(screen, float32(x), float32(y), HillRadius, fill, true)
+	strokeCircle(screen, float32(x), float32(y), HillRadius, 4, clr, true)
+}
+
+func (k *KingOfTheHill) DrawHUD(screen *ebiten.Image) {
+	percent := func(team Team) string {
+		return fmt.Sprintf("%d%%", k.scores[team]*100/HoldTime)
+	}
+	k.drawHUD(screen, percent(TeamHero), percent(TeamEnemy))
+}
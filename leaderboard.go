@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	LeaderboardFile = "leaderboard.json"
+	LeaderboardSize = 10 // 每个模式保留的记录数
+)
+
+// LeaderEntry 排行榜的一条记录
+type LeaderEntry struct {
+	Player string    `json:"player"`
+	Value  int       `json:"value"`            // 成绩，生存模式为存活帧数，计时赛为用时帧数
+	Splits []int     `json:"splits,omitempty"` // 计时赛的分段用时，从开始累计的帧数
+	Date   time.Time `json:"date"`
+}
+
+// Leaderboard 按模式分开的排行榜，保存在用户配置目录
+type Leaderboard struct {
+	Boards map[string][]LeaderEntry `json:"boards"`
+}
+
+// LoadLeaderboard 读取排行榜文件，不存在时返回空的排行榜
+func LoadLeaderboard() *Leaderboard {
+	board := &Leaderboard{Boards: map[string][]LeaderEntry{}}
+	data, err := os.ReadFile(configPath(LeaderboardFile))
+	if err == nil {
+		err = json.Unmarshal(data, board)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("load leaderboard:", err)
+	}
+	if board.Boards == nil {
+		board.Boards = map[string][]LeaderEntry{}
+	}
+	return board
+}
+
+func (l *Leaderboard) Save() {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err == nil {
+		err = writeConfig(LeaderboardFile, data)
+	}
+	if err != nil {
+		log.Println("save leaderboard:", err)
+	}
+}
+
+// Submit 提交一条记录并保存，lower表示成绩越小越好，返回名次，没有进榜返回-1
+func (l *Leaderboard) Submit(mode string, entry LeaderEntry, lower bool) int {
+	entries := append(l.Boards[mode], entry)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if lower {
+			return entries[i].Value < entries[j].Value
+		}
+		return entries[i].Value > entries[j].Value
+	})
+	rank := -1
+	for i := range entries {
+		if entries[i].Date.Equal(entry.Date) && entries[i].Value == entry.Value && i < LeaderboardSize {
+			rank = i
+		}
+	}
+	l.Boards[mode] = entries[:min(len(entries), LeaderboardSize)]
+	l.Save()
+	return rank
+}
+
+// Best 模式的最好成绩，没有记录返回nil
+func (l *Leaderboard) Best(mode string) *LeaderEntry {
+	if entries := l.Boards[mode]; len(entries) > 0 {
+		return &entries[0]
+	}
+	return nil
+}
+
+// Lines 本局的名次和排行榜前几名的文字，用于回合结算，format把成绩转成文字
+func (l *Leaderboard) Lines(mode string, rank, count int, format func(int) string) []string {
+	var lines []string
+	switch {
+	case rank == 0:
+		lines = append(lines, "新纪录！")
+	case rank > 0:
+		lines = append(lines, fmt.Sprintf("进入排行榜第%d名", rank+1))
+	}
+	for i, entry := range l.Boards[mode] {
+		if i >= count {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s  %s", i+1, format(entry.Value), entry.Player))
+	}
+	return lines
+}
@@ -1,52 +1,45 @@
 package main
 
 import (
-	"bytes"
-	_ "embed"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/audio"
-	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
-	audio2 "github.com/hajimehoshi/ebiten/v2/examples/resources/audio"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/colornames"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"image"
 	"image/color"
-	"io"
 	"log"
-	"math"
 	"math/rand"
+	"os"
 	"strconv"
 )
 
 var (
-	//go:embed hit.ogg
-	HitSound []byte
-	//go:embed explode.ogg
-	ExplodeSound []byte
-	//go:embed chsfont.ttf
-	ChsFont     []byte
 	GamepadID   ebiten.GamepadID
-	AudioCtx    = audio.NewContext(48000)
 	GroundTrees = [][2]float64{{-0.01, -0.02}, {0.1, 1}, {0.2, 0.25}, {0.45, 0.65}, {0.6, 0.12}, {1, 0.4}}
 	LifeColors  = []color.RGBA{colornames.Orangered, colornames.Yellow, colornames.Aliceblue}
 	TankAngles  = []float64{AngleZero, AngleHalfPi, AnglePi, AngleTrebleHalfPi}
 
-	// TankNames 第1个是玩家坦克
-	TankNames    = []string{"tank_sand", "tank_dark", "tank_green", "tank_red", "tank_blue"}
-	TankSpeeds   = []float64{8, 3, 4, 5, 6}
-	BulletSpeeds = []float64{32, 4, 5, 6, 7}
-	BulletNames  = []string{"bulletSand1_outline", "bulletDark1_outline", "bulletGreen1_outline", "bulletRed1_outline", "bulletBlue1_outline"}
+	// GroundMetals 金属障碍物的位置，会反弹子弹
+	GroundMetals = [][2]float64{{0.3, 0.4}, {0.7, 0.3}, {0.55, 0.8}, {0.85, 0.7}, {0.15, 0.6}}
 )
 
 func main() {
-	g := &Game{title: "坦克大战", width: 1200, height: 900}
-	g.spriteImages = LoadSpritesImage()
-	g.spritesInfos = LoadSpriteInfos()
+	if len(os.Args) > 1 && os.Args[1] == "atlas" {
+		os.Exit(atlasCommand(os.Args[2:]))
+	}
+	g := &Game{title: "坦克大战", width: 1200, height: 900, worldWidth: 2400, worldHeight: 1800}
+	g.settings = LoadSettings()
+	g.skin = LoadSkin(g.settings.Skin)
+	g.atlas = NewAtlas(g.skin.Sheet)
+	animations, err := LoadAnimations(g.atlas)
+	FatalIfError(err)
+	g.animations = animations
+	g.tankDefs = LoadTankDefs(g.atlas)
+	g.level = LoadLevel(g.atlas)
+	g.checkSkin(g.skin)
 
-	chsFont, err := opentype.Parse(ChsFont)
+	chsFont, err := opentype.Parse(g.skin.Font)
 	FatalIfError(err)
 	g.chsFont, err = opentype.NewFace(chsFont,
 		&opentype.FaceOptions{
@@ -55,13 +48,26 @@ func main() {
 		})
 	FatalIfError(err)
 
-	g.groundAudio = newInfinitePlayer(bytes.NewReader(audio2.Ragtime_ogg))
-	g.groundAudio.Play()
-	g.hitAudio = newPlayer(bytes.NewReader(HitSound))
-	g.hitAudio.SetVolume(0.4)
-	g.explodeAudio = newPlayer(bytes.NewReader(ExplodeSound))
-	g.explodeAudio.SetVolume(0.6)
+	g.events = NewEventBus()
+	g.subscribeFeedback(g.events)
+	g.audio = NewAudio(g, NewAudioBackend(), g.settings, g.events)
+	g.audio.Load("hit", g.skin.Sounds["hit"], 0.8)
+	g.audio.Load("explode", g.skin.Sounds["explode"], 1)
+	g.music = NewMusic(g, g.audio, LoadMusic(MusicDir))
+	g.camera = NewCamera(g.width, g.height, g.worldWidth, g.worldHeight)
+	g.world = NewWorld()
 	g.initGround()
+	g.minimap = NewMinimap(g)
+	g.fog = NewFog(g)
+	g.decals = NewDecals(g, g.events)
+	g.particles = NewParticles(g, g.events)
+	g.spawner = NewSpawner(g)
+	g.leaderboard = LoadLeaderboard()
+	g.scoring = NewScoring(g, g.events)
+	g.stats = NewStats(g, g.events)
+	g.debug = NewDebug(g)
+	g.options = NewOptions(g)
+	g.command = NewCommandMenu(g)
 	g.Restart()
 
 	ebiten.SetWindowTitle(g.title)
@@ -69,36 +75,54 @@ func main() {
 	ebiten.SetScreenClearedEveryFrame(false)
 	ebiten.SetWindowIcon([]image.Image{g.getIconImage()})
 	err = ebiten.RunGame(g)
+	g.stats.Save()
 	FatalIfError(err)
 }
 
 type Game struct {
-	title         string
-	width         int
-	height        int
-	spriteImages  *ebiten.Image
-	spritesInfos  map[string]SpriteInfo
-	outputSprites bool
-	hitAudio      *audio.Player
-	explodeAudio  *audio.Player
-	groundAudio   *audio.Player
-	chsFont       font.Face
-	ground        *Ground
-	hero          *Hero
-	enemy         *Chain[*Enemy]
-	updates       int
-	score         int
-	highScore     int
-	pause         bool
-	pauseCool     int
-	restartCool   int
-}
-
-type Ground struct {
-	game   *Game
-	grass1 SpriteInfo
-	grass2 SpriteInfo
-	trees  *Chain[*BoxSprite]
+	title       string
+	width       int // 窗口尺寸
+	height      int
+	worldWidth  int // 世界尺寸，可以大于窗口
+	worldHeight int
+	camera      *Camera
+	minimap     *Minimap
+	fog         *Fog
+	decals      *Decals
+	particles   *Particles
+	spawner     *Spawner
+	floatTexts  []FloatText
+	debug       *Debug
+	options     *Options
+	skin        *Skin
+	atlas       *Atlas
+	animations  Animations
+	tankDefs    *TankDefs
+	level       *Level
+	settings    *Settings
+	audio       *Audio
+	music       *Music
+	chsFont     font.Face
+	ground      *Ground
+	world       *World
+	teams       TeamSetup // 本局的阵营设置
+	hostility   *Hostility
+	leaderboard *Leaderboard
+	objective   Objective // 本局的游戏目标
+	wingmen     int       // 本局开始时的僚机数量
+	command     *CommandMenu
+	hero        *Hero
+	updates     int
+	mode        string // 本局的计分规则
+	events      *EventBus
+	stats       *Stats
+	scoring     *Scoring
+	score       int
+	highScore   int
+	roundOver   bool // 回合结束，显示结算
+	pause       bool
+	pauseCool   int
+	restartCool int
 }
 
 func (g *Game) Update() error {
@@ -107,13 +131,22 @@ func (g *Game) Update() error {
 		GamepadID = gamepadID
 		break
 	}
+	if g.options.Update() {
+		g.audio.Update()
+		g.music.Update()
+		return nil
+	}
 
 	if g.pauseCool < 30 {
 		g.pauseCool++
 	} else if ebiten.IsKeyPressed(ebiten.KeySpace) ||
 		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonCenterRight) {
 		g.pauseCool = 0
-		g.pause = !g.pause
+		if g.roundOver {
+			g.Restart()
+		} else {
+			g.pause = !g.pause
+		}
 	}
 	if g.restartCool < 30 {
 		g.restartCool++
@@ -121,20 +154,34 @@ func (g *Game) Update() error {
 		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonCenterLeft) {
 		g.Restart()
 	}
+	g.minimap.Update()
+	g.fog.Update()
+	g.audio.Update()
+	g.music.Update()
+	g.stats.UpdateToast()
+	g.debug.Update()
 	if g.pause {
 		return nil
 	}
 
-	g.hero.UpdateMove()
-	g.hero.UpdateShoot()
+	g.command.Update()
+	g.updateHealth()
+	g.updateWeapons()
+	g.updateControls()
+	g.updateBullets()
+	g.world.Flush()
 	if g.pause {
 		return nil
 	}
 
-	for enemy := g.enemy; enemy != nil; enemy = enemy.Next {
-		enemy.Value.AutoMove()
-		enemy.Value.AutoShoot()
-	}
+	g.spawner.Update()
+	g.objective.Update()
+	g.camera.Follow(g.hero.BoxSprite)
+	g.decals.Update()
+	g.particles.Update()
+	g.updateFloatTexts()
+	g.scoring.Update()
+	g.stats.Update()
 	g.updates++
 	return nil
 }
@@ -143,182 +190,120 @@ func (g *Game) Restart() {
 	g.updates = 0
 	g.restartCool = 0
 	g.pause = true
+	g.roundOver = false
 	g.score = 0
+	g.mode = g.settings.Scoring
+	g.spawner.Reset()
+	Each(g.world, g.world.teams, func(id Entity, _ Team) {
+		g.world.Remove(id) // 移除上一局的坦克和子弹，保留障碍物
+	})
+	g.world.Flush()
+	g.objective = NewObjective(g, g.settings.Objective)
+	g.teams = TeamSetupByName(g.settings.Teams)
+	if setup, ok := g.objective.Teams(); ok {
+		g.teams = setup
+	}
+	g.hostility = NewHostility(g.teams)
 	g.initHero()
+	g.initWingmen()
 	g.initEnemies()
+	g.objective.Reset()
+	g.camera.LookAt(g.hero.BoxSprite)
+	g.fog.Reset()
+	g.minimap.Invalidate()
+	Emit(g.events, Restarted{})
+}
+
+// EndRound 英雄死亡或游戏目标分出胜负后暂停并显示结算，按空格或R键重开
+func (g *Game) EndRound() {
+	g.objective.Finish()
+	g.scoring.Finish()
+	g.roundOver = true
+	g.pause = true
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
+	g.debug.BeginFrame()
 	g.ground.Draw(screen)
-	text.Draw(screen, "得分："+strconv.Itoa(g.score), g.chsFont, 3, 22, colornames.Aliceblue)
-	text.Draw(screen, "最高："+strconv.Itoa(g.highScore), g.chsFont, 3, 45, colornames.Aliceblue)
+	g.spawner.Draw(screen)
+	g.drawEntities(screen)
+	g.objective.DrawWorld(screen)
+	g.particles.Draw(screen)
+	g.fog.Draw(screen)
+	g.drawFloatTexts(screen)
+	g.command.Draw(screen)
+
+	drawText(screen, "得分："+strconv.Itoa(g.score), g.chsFont, 3, 22, g.skin.Palette.Text)
+	drawText(screen, "最高："+strconv.Itoa(g.highScore), g.chsFont, 3, 45, g.skin.Palette.Text)
 	fps := "FPS：" + strconv.Itoa(int(ebiten.ActualFPS()))
-	text.Draw(screen, fps, g.chsFont, g.width-len(fps)*10, 22, colornames.Aliceblue)
+	drawText(screen, fps, g.chsFont, g.width-len(fps)*10, 22, g.skin.Palette.Text)
 
-	desc := "空格键暂停，R键重开，WSAD或方向键移动，Ctrl或Enter键攻击，支持手柄"
+	desc := "空格键暂停，R键重开，WSAD或方向键移动，Ctrl或Enter键攻击，O键选项，支持手柄"
 	if g.pause {
-		desc = "空格键开始，R键重开，WSAD或方向键移动，Ctrl或Enter键攻击，支持手柄"
-	}
-	text.Draw(screen, desc, g.chsFont, 230, 22, colornames.Aliceblue)
-
-	for enemy := g.enemy; enemy != nil; enemy = enemy.Next {
-		enemy.Value.Draw(screen)
-	}
-	g.hero.Draw(screen)
-	if g.outputSprites {
-		g.OutputSpriteInfos()
-		g.outputSprites = false
+		desc = "空格键开始，R键重开，WSAD或方向键移动，Ctrl或Enter键攻击，O键选项，支持手柄"
 	}
+	drawText(screen, desc, g.chsFont, 230, 22, g.skin.Palette.Text)
+	g.scoring.Draw(screen)
+	g.objective.DrawHUD(screen)
+	g.stats.Draw(screen)
+	g.options.Draw(screen)
+	g.minimap.Draw(screen)
+	g.debug.Draw(screen)
 }
 
 func (g *Game) Layout(int, int) (int, int) {
 	return g.width, g.height
 }
 
-func (g *Ground) Draw(screen *ebiten.Image) {
-	// 绘制地面
-	for i := 0; i < g.game.width; i += g.grass1.Width {
-		for j := 0; j < g.game.height; j += g.grass1.Width {
-			grass := g.grass1
-			if i%(g.grass1.Width*2) == 0 {
-				grass = g.grass2
-			}
-			options := &ebiten.DrawImageOptions{}
-			options.GeoM.Translate(float64(i), float64(j))
-			options.ColorScale.SetG(0.9)
-			screen.DrawImage(GetSpriteImage(g.game.spriteImages, grass), options)
-		}
-	}
-
-	for tree := g.trees; tree != nil; tree = tree.Next {
-		tree.Value.Draw(screen)
-	}
-}
-
-func (g *Game) initGround() {
-	g.ground = &Ground{
-		game:   g,
-		grass1: g.spritesInfos["tileGrass1"],
-		grass2: g.spritesInfos["tileGrass1"],
-	}
-
-	info1 := g.spritesInfos["treeGreen_large"]
-	info2 := g.spritesInfos["treeBrown_large"]
-	for i := 0; i < len(GroundTrees); i++ {
-		info := info1
-		if i == 0 || i == 1 || i == 4 {
-			info = info2
-		}
-		g.ground.trees = &Chain[*BoxSprite]{
-			Value: &BoxSprite{
-				Img: GetSpriteImage(g.spriteImages, info),
-				X:   math.Min(float64(g.width)*GroundTrees[i][0], float64(g.width-info.Width)),
-				Y:   math.Min(float64(g.height)*GroundTrees[i][1], float64(g.height-info.Height)),
-				W:   float64(info.Width),
-				H:   float64(info.Height),
-			},
-			Next: g.ground.trees,
-		}
-	}
-}
-
 func (g *Game) initHero() {
 	// 创建玩家
-	sprite := g.spritesInfos[TankNames[0]]
-	g.hero = &Hero{
-		Tank: &Tank{
-			BoxSprite: &BoxSprite{
-				Img: GetSpriteImage(g.spriteImages, sprite),
-				A:   AnglePi,
-				X:   float64(g.width-sprite.Height) / 2,
-				Y:   float64(g.height-sprite.Height) / 2,
-				W:   float64(sprite.Height),
-				H:   float64(sprite.Height),
-			},
-			game:          g,
-			typ:           0,
-			speed:         TankSpeeds[0],
-			bulletSize:    2,
-			bulletSpeed:   BulletSpeeds[0],
-			shootCoolDown: int(BulletSpeeds[0]),
-			hitStatus:     180,
-			hitProtect:    180,
-			hitSprites:    g.tankHitSprites(),
-			life:          9,
-			maxLife:       9,
-		},
+	g.hero = &Hero{Tank: g.newTank(g.tankDefs.Get(g.tankDefs.Hero))}
+	g.hero.A = AnglePi
+	g.hero.X = (float64(g.worldWidth) - g.hero.W) / 2
+	g.hero.Y = (float64(g.worldHeight) - g.hero.H) / 2
+	if points := g.objective.SpawnPoints(TeamHero); points != nil {
+		g.hero.X = float64(g.worldWidth)*points[0][0] - g.hero.W/2
+		g.hero.Y = float64(g.worldHeight)*points[0][1] - g.hero.H/2
+	}
+	g.hero.hitStatus = g.hero.hitProtect
+	g.hero.onDeath = func() {
+		Emit(g.events, HeroDied{Hero: g.hero})
+		if !g.objective.Respawn(g.hero.Tank) {
+			g.EndRound()
+		}
 	}
+	g.addTank(g.hero.Tank, TeamHero, g.hero)
 }
 
 func (g *Game) initEnemies() {
 	// 创建敌人
-	g.enemy = nil
-	for i := 0; i < 10; i++ {
-		typ := 1 + i%(len(TankNames)-1)
-		sprite := g.spritesInfos[TankNames[typ]]
-		enemy := &Chain[*Enemy]{
-			Value: &Enemy{
-				Tank: &Tank{
-					BoxSprite: &BoxSprite{
-						Img: GetSpriteImage(g.spriteImages, sprite),
-						A:   TankAngles[rand.Intn(len(TankAngles))],
-						X:   float64(rand.Intn(g.width - sprite.Height)),
-						Y:   float64(rand.Intn(g.height - sprite.Height)),
-						W:   float64(sprite.Height),
-						H:   float64(sprite.Height),
-					},
-					game:          g,
-					typ:           typ,
-					maxLife:       typ,
-					speed:         TankSpeeds[typ],
-					bulletSize:    1.2,
-					bulletSpeed:   BulletSpeeds[typ],
-					shootCoolDown: int(BulletSpeeds[typ]),
-					hitSprites:    g.tankHitSprites(),
-				},
-			},
-			Next: g.enemy,
-		}
-		enemy.Value.reborn() // 出生
-		g.enemy = enemy
+	count := g.teams.Count
+	if count == 0 {
+		count = g.tankDefs.EnemyCount
+	}
+	for i := 0; i < count; i++ {
+		g.addEnemy(g.teams.EnemyTeam(i), 0)
 	}
 }
 
-func (g *Game) tankHitSprites() [5]SpriteInfo {
-	return [5]SpriteInfo{
-		g.spritesInfos["explosion5"],
-		g.spritesInfos["explosion4"],
-		g.spritesInfos["explosion3"],
-		g.spritesInfos["explosion2"],
-		g.spritesInfos["explosion1"]}
-}
-
-func newPlayer(reader io.Reader) *audio.Player {
-	stream, err := vorbis.DecodeWithoutResampling(reader)
-	FatalIfError(err)
-	player, err := AudioCtx.NewPlayer(stream)
-	FatalIfError(err)
-	player.SetVolume(0.2)
-	return player
-}
-
-func newInfinitePlayer(reader io.Reader) *audio.Player {
-	stream, err := vorbis.DecodeWithoutResampling(reader)
-	FatalIfError(err)
-	infiniteLoop := audio.NewInfiniteLoop(stream, stream.Length())
-	player, err := AudioCtx.NewPlayer(infiniteLoop)
-	FatalIfError(err)
-	player.SetVolume(0.2)
-	return player
+// addEnemy 创建一辆随机的AI坦克，等待wait帧后排队出生
+func (g *Game) addEnemy(team Team, wait int) {
+	enemy := &Enemy{Tank: g.newTank(g.tankDefs.RandomEnemy())}
+	enemy.A = TankAngles[rand.Intn(len(TankAngles))]
+	enemy.life = 0
+	enemy.onDeath = func() { g.spawner.Killed(enemy.Tank) }
+	g.addTank(enemy.Tank, team, enemy)
+	g.spawner.Enqueue(enemy.Tank, wait) // 排队出生
 }
 
 func (g *Game) getIconImage() *ebiten.Image {
-	tankInfo := g.spritesInfos["tank_sand"]
-	iconImage := ebiten.NewImage(tankInfo.Width, tankInfo.Height)
+	tankInfo := g.sprite(g.tankDefs.Get(g.tankDefs.Hero).Sprite)
+	w, h := tankInfo.Size()
+	iconImage := ebiten.NewImage(w, h)
 	options := &ebiten.DrawImageOptions{}
-	options.GeoM.Translate(float64(-tankInfo.X-tankInfo.Width), float64(-tankInfo.Y-tankInfo.Height))
+	options.GeoM.Translate(float64(-w), float64(-h))
 	options.GeoM.Rotate(AnglePi)
-	iconImage.DrawImage(g.spriteImages, options)
+	iconImage.DrawImage(g.atlas.Image(tankInfo), options)
 	return iconImage
 }
 
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+)
+
+var MinimapSizes = []int{160, 240, 320}
+
+// Minimap 角落的小地图，地形缓存在离屏图片中，每帧只刷新动态标记
+type Minimap struct {
+	game      *Game
+	visible   bool
+	sizeIndex int     // 小地图宽度在MinimapSizes中的下标
+	alpha     float32 // 不透明度
+	sightOnly bool    // 只显示英雄视线内的敌人
+	terrain   *ebiten.Image
+	image     *ebiten.Image
+}
+
+func NewMinimap(g *Game) *Minimap {
+	return &Minimap{game: g, visible: true, sizeIndex: 1, alpha: 0.8}
+}
+
+// Update M键开关，逗号和句号调整大小，减号和等号调整透明度，L键切换视线过滤
+func (m *Minimap) Update() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		m.visible = !m.visible
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyComma) && m.sizeIndex > 0 {
+		m.sizeIndex--
+		m.Invalidate()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) && m.sizeIndex < len(MinimapSizes)-1 {
+		m.sizeIndex++
+		m.Invalidate()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+		m.alpha = float32(math.Max(0.2, float64(m.alpha)-0.1))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+		m.alpha = float32(math.Min(1, float64(m.alpha)+0.1))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		m.sightOnly = !m.sightOnly
+	}
+}
+
+// Invalidate 地形或尺寸变化后丢弃缓存，下次绘制时重建
+func (m *Minimap) Invalidate() {
+	if m.terrain != nil {
+		m.terrain.Dispose()
+		m.image.Dispose()
+	}
+	m.terrain, m.image = nil, nil
+}
+
+func (m *Minimap) scale() float64 {
+	return float64(MinimapSizes[m.sizeIndex]) / float64(m.game.worldWidth)
+}
+
+// renderTerrain 把缓存的地面层缩小绘制到小地图，地形和障碍物与主画面一致
+func (m *Minimap) renderTerrain() {
+	scale := m.scale()
+	w := MinimapSizes[m.sizeIndex]
+	h := int(float64(m.game.worldHeight) * scale)
+	m.terrain = ebiten.NewImage(w, h)
+	m.image = ebiten.NewImage(w, h)
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Scale(scale, scale)
+	options.Filter = ebiten.FilterLinear
+	drawImage(m.terrain, m.game.ground.layer, options)
+	drawImage(m.terrain, m.game.ground.top, options)
+	strokeRect(m.terrain, 0, 0, float32(w), float32(h), 2, colornames.Aliceblue, false)
+}
+
+func (m *Minimap) drawMarker(s *BoxSprite, scale float64, r float32, clr color.Color) {
+	w, h := s.GetDrawWH()
+	fillCircle(m.image, float32((s.X+w/2)*scale), float32((s.Y+h/2)*scale), r, clr, true)
+}
+
+func (m *Minimap) Draw(screen *ebiten.Image) {
+	if !m.visible {
+		return
+	}
+	if m.terrain == nil {
+		m.renderTerrain()
+	}
+	g := m.game
+	scale := m.scale()
+	drawImage(m.image, m.terrain, nil)
+
+	// 视口范围
+	cam := g.camera
+	strokeRect(m.image, float32(cam.X*scale), float32(cam.Y*scale),
+		float32(cam.W*scale), float32(cam.H*scale), 1, colornames.White, false)
+
+	// 敌对的坦克为红色，队友为绿色，其他为灰色
+	Each(g.world, g.world.tanks, func(id Entity, tk *Tank) {
+		if id == g.hero.id || tk.life <= 0 || !g.fog.Visible(tk.BoxSprite) ||
+			(m.sightOnly && !g.InSight(g.hero.BoxSprite, tk.BoxSprite)) {
+			return
+		}
+		clr := colornames.Gray
+		if g.hostile(g.hero.id, id) {
+			clr = colornames.Orangered
+		} else if g.world.teams[id] == g.world.teams[g.hero.id] {
+			clr = colornames.Limegreen
+		}
+		m.drawMarker(tk.BoxSprite, scale, 3, clr)
+	})
+	if g.hero.life > 0 {
+		m.drawMarker(g.hero.BoxSprite, scale, 4, colornames.Yellow)
+	}
+
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Translate(float64(g.width-m.image.Bounds().Dx()-10), 32)
+	options.ColorScale.ScaleAlpha(m.alpha)
+	drawImage(screen, m.image, options)
+}
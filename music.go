@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"github.com/hajimehoshi/ebiten/v2"
+	audio2 "github.com/hajimehoshi/ebiten/v2/examples/resources/audio"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	MusicDir       = "music" // 工作目录下的音乐目录，每个场景一个子目录，紧张的音乐放在场景的intense子目录
+	MusicFade      = 120     // 交叉淡入淡出的帧数
+	IntenseRange   = 600     // 统计附近敌人的距离
+	IntenseEnemies = 4       // 附近的敌人达到这个数量时切换到紧张的音乐
+	IntenseHold    = 300     // 紧张的音乐至少持续的帧数
+)
+
+var (
+	MusicScenes = []string{"menu", "battle", "gameover"}
+	MusicExts   = map[string]bool{".ogg": true, ".mp3": true, ".wav": true}
+)
+
+// MusicTrack 一首音乐，按需打开
+type MusicTrack struct {
+	Name string
+	open func() (io.ReadSeekCloser, error)
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+// LoadMusic 读取音乐目录下各场景的音乐，场景没有音乐时沿用战斗音乐，战斗没有音乐时使用内置音乐
+func LoadMusic(dir string) map[string][]MusicTrack {
+	playlists := map[string][]MusicTrack{}
+	for _, scene := range MusicScenes {
+		for _, key := range []string{scene, scene + "/intense"} {
+			entries, err := os.ReadDir(filepath.Join(dir, key))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || !MusicExts[strings.ToLower(filepath.Ext(entry.Name()))] {
+					continue
+				}
+				path := filepath.Join(dir, key, entry.Name())
+				playlists[key] = append(playlists[key], MusicTrack{Name: path, open: func() (io.ReadSeekCloser, error) {
+					return os.Open(path)
+				}})
+			}
+		}
+	}
+	if len(playlists["battle"]) == 0 {
+		playlists["battle"] = []MusicTrack{{Name: "ragtime.ogg", open: func() (io.ReadSeekCloser, error) {
+			return nopCloser{bytes.NewReader(audio2.Ragtime_ogg)}, nil
+		}}}
+	}
+	for _, scene := range MusicScenes {
+		if len(playlists[scene]) == 0 {
+			playlists[scene] = playlists["battle"]
+		}
+	}
+	return playlists
+}
+
+// musicVoice 正在播放的一首音乐
+type musicVoice struct {
+	voice  Voice
+	src    io.Closer
+	length time.Duration
+	fade   float64 // 当前的音量比例
+	step   float64 // 每帧音量比例的变化，负数表示淡出
+}
+
+// ending 是否快要播完，需要开始淡入下一首
+func (v *musicVoice) ending() bool {
+	return v.step > 0 && v.length > 0 && v.voice.Current() >= v.length-time.Second*MusicFade/ebiten.DefaultTPS
+}
+
+func (v *musicVoice) close() {
+	v.voice.Pause()
+	_ = v.voice.Close()
+	_ = v.src.Close()
+}
+
+// Music 音乐系统，按场景播放歌单，歌曲之间交叉淡入淡出，
+// 附近敌人较多或首领出现时切换到紧张的音乐
+type Music struct {
+	game      *Game
+	audio     *Audio
+	playlists map[string][]MusicTrack
+	next      map[string]int // 每个歌单下一首的下标
+	key       string         // 当前的歌单
+	intense   int            // 紧张音乐剩余的帧数
+	voices    []*musicVoice  // 最后一个是当前的音乐，其余正在淡出
+}
+
+func NewMusic(g *Game, a *Audio, playlists map[string][]MusicTrack) *Music {
+	return &Music{game: g, audio: a, playlists: playlists, next: map[string]int{}}
+}
+
+// scene 当前的场景
+func (m *Music) scene() string {
+	g := m.game
+	if g.roundOver {
+		return "gameover"
+	}
+	if g.pause && g.updates == 0 {
+		return "menu"
+	}
+	return "battle"
+}
+
+// tense 附近的敌对坦克是否较多或者有首领
+func (m *Music) tense() bool {
+	hero := m.game.hero
+	w := m.game.world
+	near, boss := 0, false
+	Each(w, w.tanks, func(id Entity, e *Tank) {
+		if !m.game.hostile(m.game.hero.id, id) || e.life <= 0 {
+			return
+		}
+		boss = boss || e.def.Boss
+		if math.Hypot(e.X-hero.X, e.Y-hero.Y) < IntenseRange {
+			near++
+		}
+	})
+	return boss || near >= IntenseEnemies
+}
+
+func (m *Music) Update() {
+	key := m.scene()
+	if !m.game.pause && m.tense() {
+		m.intense = IntenseHold
+	} else if m.intense > 0 && !m.game.pause {
+		m.intense--
+	}
+	if m.intense > 0 && len(m.playlists[key+"/intense"]) > 0 {
+		key += "/intense"
+	}
+	if key != m.key {
+		m.key = key
+		m.play()
+	} else if n := len(m.voices); n == 0 || m.voices[n-1].ending() {
+		m.play()
+	}
+
+	volume := m.audio.MusicVolume()
+	remain := m.voices[:0]
+	for _, v := range m.voices {
+		v.fade = math.Max(0, math.Min(v.fade+v.step, 1))
+		if v.fade == 0 && v.step < 0 {
+			v.close()
+			continue
+		}
+		v.voice.SetVolume(volume * v.fade)
+		remain = append(remain, v)
+	}
+	m.voices = remain
+}
+
+// play 淡出正在播放的音乐，淡入当前歌单的下一首
+func (m *Music) play() {
+	for _, v := range m.voices {
+		v.step = -1.0 / MusicFade
+	}
+	tracks := m.playlists[m.key]
+	for len(tracks) > 0 {
+		i := m.next[m.key] % len(tracks)
+		m.next[m.key] = i + 1
+		v, err := m.open(tracks[i])
+		if err == nil {
+			m.voices = append(m.voices, v)
+			return
+		}
+		// 无法播放的音乐从歌单中移除
+		log.Println("play music:", err)
+		tracks = append(tracks[:i:i], tracks[i+1:]...)
+		m.playlists[m.key] = tracks
+	}
+}
+
+func (m *Music) open(track MusicTrack) (*musicVoice, error) {
+	src, err := track.open()
+	if err != nil {
+		return nil, err
+	}
+	pcm, length, err := m.audio.backend.Decode(track.Name, src)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+	v := &musicVoice{
+		voice:  m.audio.backend.NewVoice(pcm),
+		src:    src,
+		length: time.Duration(length) * time.Second / (4 * SampleRate),
+		step:   1.0 / MusicFade,
+	}
+	if len(m.voices) == 0 {
+		v.fade = 1 // 没有正在播放的音乐时直接开始
+	}
+	v.voice.SetVolume(0)
+	v.voice.Play()
+	return v, nil
+}
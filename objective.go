@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+)
+
+const DefaultObjective = "classic"
+
+// Objective 游戏目标，决定阵营、出生点、重生规则、AI的去向、回合的胜负和HUD，
+// 经典模式下消灭敌人得分，英雄死亡时回合结束
+type Objective interface {
+	Name() string
+	Reset()
+	Update()
+	Teams() (TeamSetup, bool)           // 模式指定的阵营设置，否则使用选项中的设置
+	SpawnPoints(team Team) [][2]float64 // 阵营的出生点，按世界尺寸的比例，nil表示使用默认出生点
+	Goal(tk *Tank) (x, y float64, ok bool)
+	Respawn(tk *Tank) bool // 英雄或僚机阵亡后是否安排重生，否则英雄阵亡时回合结束
+	Pace() float64         // AI坦克移动和子弹速度的倍率
+	KillWaves() bool       // 击毁足够数量的敌人后是否进入下一波，否则由游戏目标推进波数
+	Finish()               // 回合结束时调用，记录成绩
+	Result() []string      // 结算时显示在得分明细前面的内容
+	DrawWorld(screen *ebiten.Image)
+	DrawHUD(screen *ebiten.Image)
+}
+
+// ObjectiveModes 可选的游戏目标，在选项中切换，重开后生效
+var ObjectiveModes = []struct {
+	Name  string
+	Label string
+	New   func(g *Game) Objective
+}{
+	{"classic", "经典", func(g *Game) Objective { return classicObjective{game: g} }},
+	{"ctf", "夺旗", func(g *Game) Objective { return NewCaptureTheFlag(g) }},
+	{"koth", "占山为王", func(g *Game) Objective { return NewKingOfTheHill(g) }},
+	{"survival", "生存", func(g *Game) Objective { return NewSurvival(g) }},
+	{"timeattack", "计时赛", func(g *Game) Objective { return NewTimeAttack(g) }},
+}
+
+// ObjectiveTeams 目标模式的阵营，AI坦克交替分配，加上英雄双方各3辆，两方互相敌对
+var ObjectiveTeams = TeamSetup{Name: "objective", Label: "对抗", Count: 5,
+	Enemies: []Team{TeamEnemy, TeamHero}, Hostile: [][2]Team{{TeamHero, TeamEnemy}}}
+
+// TeamBases 目标模式中双方基地的中心和出生点，按世界尺寸的比例
+var TeamBases = map[Team][][2]float64{
+	TeamHero:  {{0.06, 0.5}, {0.05, 0.38}, {0.05, 0.62}, {0.11, 0.44}, {0.11, 0.56}},
+	TeamEnemy: {{0.94, 0.5}, {0.95, 0.38}, {0.95, 0.62}, {0.89, 0.44}, {0.89, 0.56}},
+}
+
+var TeamLabels = map[Team]string{TeamHero: "我方", TeamEnemy: "敌方"}
+
+var TeamColors = map[Team]color.RGBA{TeamHero: colornames.Limegreen, TeamEnemy: colornames.Orangered}
+
+// NewObjective 按名称创建游戏目标，找不到时使用经典模式
+func NewObjective(g *Game, name string) Objective {
+	for _, mode := range ObjectiveModes {
+		if mode.Name == name {
+			return mode.New(g)
+		}
+	}
+	return ObjectiveModes[0].New(g)
+}
+
+func objectiveLabel(name string) string {
+	for _, mode := range ObjectiveModes {
+		if mode.Name == name {
+			return mode.Label
+		}
+	}
+	return ObjectiveModes[0].Label
+}
+
+// classicObjective 经典模式，没有额外的目标，AI随得分加快
+type classicObjective struct {
+	game *Game
+}
+
+func (classicObjective) Name() string                        { return "classic" }
+func (classicObjective) Reset()                              {}
+func (classicObjective) Update()                             {}
+func (classicObjective) Teams() (TeamSetup, bool)            { return TeamSetup{}, false }
+func (classicObjective) SpawnPoints(Team) [][2]float64       { return nil }
+func (classicObjective) Goal(*Tank) (float64, float64, bool) { return 0, 0, false }
+func (classicObjective) Respawn(*Tank) bool                  { return false }
+func (c classicObjective) Pace() float64                     { return scorePace(c.game) }
+func (classicObjective) KillWaves() bool                     { return true }
+func (classicObjective) Finish()                             {}
+func (classicObjective) Result() []string                    { return nil }
+func (classicObjective) DrawWorld(*ebiten.Image)             {}
+func (classicObjective) DrawHUD(*ebiten.Image)               {}
+
+// scorePace 按得分加快AI，每1000分加快一倍
+func scorePace(g *Game) float64 {
+	return 1 + float64(g.score)/1000
+}
+
+// formatClock 把帧数格式化为分:秒
+func formatClock(frames int) string {
+	seconds := frames / 60
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// formatSplit 把帧数格式化为分:秒.百分秒
+func formatSplit(frames int) string {
+	return fmt.Sprintf("%s.%02d", formatClock(frames), frames%60*100/60)
+}
+
+// match 目标模式共用的比赛状态：双方得分、回合计时和基地重生，
+// 每局时长和重生等待时间由各模式决定
+type match struct {
+	game         *Game
+	roundTime    int // 每局的帧数
+	respawnDelay int // 英雄和僚机阵亡后等待重生的帧数
+	timer        int // 回合剩余帧数
+	scores       map[Team]int
+	winner       Team
+	over         bool
+}
+
+func (m *match) Reset() {
+	m.timer = m.roundTime
+	m.scores = map[Team]int{}
+	m.winner, m.over = TeamNone, false
+}
+
+func (m *match) Teams() (TeamSetup, bool) {
+	return ObjectiveTeams, true
+}
+
+func (m *match) SpawnPoints(team Team) [][2]float64 {
+	return TeamBases[team]
+}
+
+func (m *match) Respawn(tk *Tank) bool {
+	m.game.spawner.Enqueue(tk, m.respawnDelay)
+	return true
+}
+
+func (m *match) Pace() float64 {
+	return scorePace(m.game)
+}
+
+func (m *match) KillWaves() bool {
+	return true
+}
+
+func (m *match) Finish() {}
+
+// tick 推进回合计时，时间到时得分高的一方获胜
+func (m *match) tick() {
+	if m.over {
+		return
+	}
+	m.timer--
+	if m.timer > 0 {
+		return
+	}
+	switch {
+	case m.scores[TeamHero] > m.scores[TeamEnemy]:
+		m.finish(TeamHero)
+	case m.scores[TeamHero] < m.scores[TeamEnemy]:
+		m.finish(TeamEnemy)
+	default:
+		m.finish(TeamNone)
+	}
+}
+
+func (m *match) finish(winner Team) {
+	m.winner, m.over = winner, true
+	m.game.EndRound()
+}
+
+func (m *match) Result() []string {
+	if !m.over {
+		return nil
+	}
+	if m.winner == TeamNone {
+		return []string{"平局"}
+	}
+	return []string{TeamLabels[m.winner] + "获胜"}
+}
+
+// base 阵营基地中心的世界坐标
+func (m *match) base(team Team) (float64, float64) {
+	pos := TeamBases[team][0]
+	return float64(m.game.worldWidth) * pos[0], float64(m.game.worldHeight) * pos[1]
+}
+
+// drawBases 用阵营颜色的圆圈标出基地
+func (m *match) drawBases(screen *ebiten.Image) {
+	for _, team := range []Team{TeamHero, TeamEnemy} {
+		x, y := m.game.camera.ToScreen(m.base(team))
+		clr := TeamColors[team]
+		clr.A = 0x80
+		strokeCircle(screen, float32(x), float32(y), BaseRadius, 3, clr, true)
+	}
+}
+
+// drawHUD 在屏幕上方居中显示双方的比分和剩余时间
+func (m *match) drawHUD(screen *ebiten.Image, hero, enemy string) {
+	g := m.game
+	line := fmt.Sprintf("%s %s : %s %s   %s", TeamLabels[TeamHero], hero, enemy,
+		TeamLabels[TeamEnemy], formatClock(m.timer+59))
+	drawHUDLine(screen, g, line, 0, colornames.Yellow)
+}
+
+// drawHUDLine 在屏幕上方居中显示一行HUD，row从0开始
+func drawHUDLine(screen *ebiten.Image, g *Game, line string, row int, clr color.Color) {
+	x := (g.width - text.BoundString(g.chsFont, line).Dx()) / 2
+	drawText(screen, line, g.chsFont, x, 72+row*24, clr)
+}
+
+// scored 阵营得分，英雄一方得分时计入英雄的分数
+func (m *match) scored(team Team, points int) {
+	Emit(m.game.events, ObjectiveScored{Team: team, Points: points})
+}
+
+// goalOffset 让同一目标的坦克分散在目标周围
+func goalOffset(tk *Tank, radius float64) (float64, float64) {
+	a := float64(tk.id) * 2.4
+	return math.Cos(a) * radius, math.Sin(a) * radius
+}
+
+// otherTeam 目标模式中的对方阵营
+func otherTeam(team Team) Team {
+	if team == TeamHero {
+		return TeamEnemy
+	}
+	return TeamHero
+}
@@ -0,0 +1,33 @@
+package main
+
+import "math"
+
+const TreeResist = 0.4 // 子弹穿透树时损失的伤害
+
+// addObstacle 按世界尺寸的比例放置障碍物，不超出世界的右下边缘，
+// 金属障碍物反弹子弹，其他障碍物可以被穿透
+func (g *Ground) addObstacle(info SpriteInfo, pos [2]float64, scale float64, metal bool, resist float64) {
+	infoW, infoH := info.Size()
+	w, h := float64(infoW)*scale, float64(infoH)*scale
+	game := g.game
+	id := game.world.Create()
+	box := &BoxSprite{
+		Img: game.atlas.Image(info),
+		X:   math.Min(float64(game.worldWidth)*pos[0], float64(game.worldWidth)-w),
+		Y:   math.Min(float64(game.worldHeight)*pos[1], float64(game.worldHeight)-h),
+		W:   w,
+		H:   h,
+	}
+	box.SetPivot(info)
+	game.world.transforms[id] = box
+	game.world.colliders[id] = &Collider{Solid: true, Static: true, Metal: metal, Resist: resist}
+}
+
+// eachObstacle 遍历所有障碍物
+func (g *Game) eachObstacle(fn func(id Entity, box *BoxSprite)) {
+	Each(g.world, g.world.colliders, func(id Entity, collider *Collider) {
+		if collider.Static {
+			fn(id, g.world.transforms[id])
+		}
+	})
+}
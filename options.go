@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Options 选项面板，O键打开或关闭，上下键选择，左右键修改，修改后立即保存
+type Options struct {
+	game    *Game
+	visible bool
+	row     int
+	skins   []string
+}
+
+// optionRow 选项面板的一行
+type optionRow struct {
+	label  string
+	value  func() string
+	change func(step int)
+}
+
+func NewOptions(g *Game) *Options {
+	return &Options{game: g}
+}
+
+func (o *Options) Visible() bool {
+	return o.visible
+}
+
+func (o *Options) rows() []optionRow {
+	s := o.game.settings
+	volume := func(label string, v *float64) optionRow {
+		return optionRow{label, func() string {
+			return fmt.Sprintf("%.0f%%", *v*100)
+		}, func(step int) {
+			*v = math.Max(0, math.Min(1, math.Round((*v+float64(step)*VolumeStep)*10)/10))
+		}}
+	}
+	return []optionRow{
+		volume("主音量", &s.MasterVolume),
+		volume("音乐", &s.MusicVolume),
+		volume("音效", &s.SFXVolume),
+		{"静音", func() string {
+			if s.Muted {
+				return "开"
+			}
+			return "关"
+		}, func(int) {
+			s.Muted = !s.Muted
+		}},
+		{"皮肤", func() string {
+			if s.Skin != o.game.skin.Name && !(s.Skin == "" && o.game.skin.Name == DefaultSkin) {
+				return s.Skin + "（重启后生效）"
+			}
+			return o.game.skin.Name
+		}, func(step int) {
+			current := 0
+			for i, skin := range o.skins {
+				if skin == s.Skin {
+					current = i
+				}
+			}
+			s.Skin = o.skins[(current+step+len(o.skins))%len(o.skins)]
+		}},
+		{"模式", func() string {
+			label := objectiveLabel(s.Objective)
+			if NewObjective(o.game, s.Objective).Name() != o.game.objective.Name() {
+				return label + "（重开后生效）"
+			}
+			return label
+		}, func(step int) {
+			current := 0
+			for i, mode := range ObjectiveModes {
+				if mode.Name == s.Objective {
+					current = i
+				}
+			}
+			s.Objective = ObjectiveModes[(current+step+len(ObjectiveModes))%len(ObjectiveModes)].Name
+		}},
+		{"计分", func() string {
+			rules := ScoreRulesByName(s.Scoring)
+			if rules.Name != ScoreRulesByName(o.game.mode).Name {
+				return rules.Label + "（重开后生效）"
+			}
+			return rules.Label
+		}, func(step int) {
+			current := 0
+			for i, rules := range ScoreModes {
+				if rules.Name == ScoreRulesByName(s.Scoring).Name {
+					current = i
+				}
+			}
+			s.Scoring = ScoreModes[(current+step+len(ScoreModes))%len(ScoreModes)].Name
+		}},
+		{"阵营", func() string {
+			if _, ok := NewObjective(o.game, s.Objective).Teams(); ok {
+				return "由模式决定"
+			}
+			setup := TeamSetupByName(s.Teams)
+			if setup.Name != o.game.teams.Name {
+				return setup.Label + "（重开后生效）"
+			}
+			return setup.Label
+		}, func(step int) {
+			current := 0
+			for i, setup := range TeamSetups {
+				if setup.Name == TeamSetupByName(s.Teams).Name {
+					current = i
+				}
+			}
+			s.Teams = TeamSetups[(current+step+len(TeamSetups))%len(TeamSetups)].Name
+		}},
+		{"僚机", func() string {
+			if s.Wingmen != o.game.wingmen {
+				return strconv.Itoa(s.Wingmen) + "（重开后生效）"
+			}
+			return strconv.Itoa(s.Wingmen)
+		}, func(step int) {
+			s.Wingmen = (s.Wingmen + step + MaxWingmen + 1) % (MaxWingmen + 1)
+		}},
+		{"友军伤害", func() string {
+			if s.FriendlyFire {
+				return "开"
+			}
+			return "关"
+		}, func(int) {
+			s.FriendlyFire = !s.FriendlyFire
+		}},
+	}
+}
+
+// Update 处理面板的输入，面板打开时返回true，游戏不再处理其他输入
+func (o *Options) Update() bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) ||
+		o.visible && inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		o.visible = !o.visible
+		if o.visible {
+			o.skins = ListSkins()
+			o.game.pause = true
+		}
+	}
+	if !o.visible {
+		return false
+	}
+	rows := o.rows()
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		o.row = (o.row + len(rows) - 1) % len(rows)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		o.row = (o.row + 1) % len(rows)
+	}
+	step := 0
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		step = -1
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		step = 1
+	}
+	if step != 0 {
+		rows[o.row].change(step)
+		o.game.settings.Save()
+	}
+	return true
+}
+
+func (o *Options) Draw(screen *ebiten.Image) {
+	if !o.visible {
+		return
+	}
+	g := o.game
+	rows := o.rows()
+	w, h := float32(420), float32(80+len(rows)*32)
+	x, y := (float32(g.width)-w)/2, (float32(g.height)-h)/2
+	fillRect(screen, x, y, w, h, color.RGBA{A: 0xd0}, false)
+	drawText(screen, "选项", g.chsFont, int(x)+20, int(y)+32, colornames.Yellow)
+	for i, row := range rows {
+		clr := g.skin.Palette.Text
+		if i == o.row {
+			clr = colornames.Orange
+		}
+		drawText(screen, row.label, g.chsFont, int(x)+20, int(y)+68+i*32, clr)
+		drawText(screen, row.value(), g.chsFont, int(x)+140, int(y)+68+i*32, clr)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"math"
+	"math/rand"
+)
+
+const MaxParticles = 800 // 粒子总数上限，超出时丢弃新粒子
+
+// Particle 粒子，生命周期内移动、旋转、缩放并淡出
+type Particle struct {
+	info    SpriteInfo
+	anim    *Animator // 不为空时每帧用动画的当前帧替换info
+	X       float64   // 中心点的世界坐标
+	Y       float64
+	VX      float64
+	VY      float64
+	A       float64
+	Spin    float64 // 每帧旋转的角度
+	Scale   float64
+	Grow    float64 // 每帧缩放的增量
+	Drag    float64 // 每帧速度保留的比例
+	R       float32
+	G       float32
+	B       float32
+	Alpha   float32
+	Life    int
+	MaxLife int
+}
+
+// Particles 粒子系统，所有粒子都来自精灵图集，每帧一次批量绘制
+type Particles struct {
+	game     *Game
+	items    []Particle
+	vertices []ebiten.Vertex
+	indices  []uint16
+	muzzles  [2]*Animation
+	smoke    *Animation
+	thin     SpriteInfo
+}
+
+func NewParticles(g *Game, bus *EventBus) *Particles {
+	p := &Particles{
+		game:    g,
+		items:   make([]Particle, 0, MaxParticles),
+		muzzles: [2]*Animation{g.animation("muzzleHero"), g.animation("muzzleEnemy")},
+		smoke:   g.animation("smoke"),
+		thin:    g.sprite("shotThin"),
+	}
+	Subscribe(bus, func(e BulletFired) {
+		p.MuzzleFlash(e.Bullet, e.Tank != g.hero.Tank)
+	})
+	Subscribe(bus, func(e BulletIntercepted) {
+		w, h := e.Bullet.GetDrawWH()
+		p.Sparks(e.Bullet.X+w/2, e.Bullet.Y+h/2)
+	})
+	Subscribe(bus, func(e TankDestroyed) {
+		p.Explode(e.Tank)
+	})
+	Subscribe(bus, func(Restarted) {
+		p.Clear()
+	})
+	return p
+}
+
+func (p *Particles) Clear() {
+	p.items = p.items[:0]
+}
+
+// Emit 发射一个粒子，达到上限时丢弃
+func (p *Particles) Emit(pt Particle) {
+	if len(p.items) >= MaxParticles {
+		return
+	}
+	if pt.Drag == 0 {
+		pt.Drag = 1
+	}
+	pt.MaxLife = pt.Life
+	if pt.anim != nil {
+		pt.info = pt.anim.Frame()
+	}
+	p.items = append(p.items, pt)
+}
+
+// MuzzleFlash 子弹出膛时的炮口火光
+func (p *Particles) MuzzleFlash(b *Bullet, red bool) {
+	anim := p.muzzles[0]
+	if red {
+		anim = p.muzzles[1]
+	}
+	w, h := b.GetDrawWH()
+	p.Emit(Particle{anim: anim.Play(nil), X: b.X + w/2, Y: b.Y + h/2, A: b.A,
+		Scale: 0.6, Grow: 0.05, R: 1, G: 1, B: 1, Alpha: 1, Life: 5})
+}
+
+// Smoke 爆炸后缓慢上升扩散的烟雾
+func (p *Particles) Smoke(x, y, size float64) {
+	for i := 0; i < 6; i++ {
+		anim := p.smoke.Play(nil)
+		anim.SetFrame(rand.Intn(len(p.smoke.Frames)))
+		w, _ := anim.Frame().Size()
+		p.Emit(Particle{anim: anim,
+			X: x + (rand.Float64()-0.5)*size/2, Y: y + (rand.Float64()-0.5)*size/2,
+			VX: (rand.Float64() - 0.5) * 0.8, VY: -0.3 - rand.Float64()*0.5,
+			A: rand.Float64() * math.Pi * 2, Spin: (rand.Float64() - 0.5) * 0.03,
+			Scale: size / float64(w) * 0.5, Grow: 0.006, Drag: 0.99,
+			R: 0.5, G: 0.5, B: 0.5, Alpha: 0.7, Life: 60 + rand.Intn(60)})
+	}
+}
+
+// Debris 坦克爆炸时四散的碎片
+func (p *Particles) Debris(x, y float64) {
+	for i := 0; i < 12; i++ {
+		a := rand.Float64() * math.Pi * 2
+		v := 3 + rand.Float64()*5
+		p.Emit(Particle{info: p.thin, X: x, Y: y, VX: math.Cos(a) * v, VY: math.Sin(a) * v,
+			A: rand.Float64() * math.Pi * 2, Spin: (rand.Float64() - 0.5) * 0.4,
+			Scale: 0.3 + rand.Float64()*0.3, Drag: 0.92,
+			R: 0.25, G: 0.22, B: 0.2, Alpha: 1, Life: 30 + rand.Intn(30)})
+	}
+}
+
+// Sparks 子弹相撞时的火花，沿速度方向拉长
+func (p *Particles) Sparks(x, y float64) {
+	for i := 0; i < 10; i++ {
+		a := rand.Float64() * math.Pi * 2
+		v := 2 + rand.Float64()*4
+		p.Emit(Particle{info: p.thin, X: x, Y: y, VX: math.Cos(a) * v, VY: math.Sin(a) * v,
+			A: a + AngleHalfPi, Scale: 0.25, Grow: -0.01, Drag: 0.9,
+			R: 1, G: 0.9, B: 0.4, Alpha: 1, Life: 10 + rand.Intn(10)})
+	}
+}
+
+// Explode 坦克爆炸时的烟雾和碎片
+func (p *Particles) Explode(tk *Tank) {
+	w, h := tk.GetDrawWH()
+	p.Smoke(tk.X+w/2, tk.Y+h/2, w)
+	p.Debris(tk.X+w/2, tk.Y+h/2)
+}
+
+func (p *Particles) Update() {
+	alive := p.items[:0]
+	for _, pt := range p.items {
+		pt.Life--
+		if pt.Life < 1 {
+			continue
+		}
+		pt.X += pt.VX
+		pt.Y += pt.VY
+		pt.VX *= pt.Drag
+		pt.VY *= pt.Drag
+		pt.A += pt.Spin
+		pt.Scale = math.Max(0, pt.Scale+pt.Grow)
+		if pt.anim != nil {
+			pt.anim.Update()
+			pt.info = pt.anim.Frame()
+		}
+		alive = append(alive, pt)
+	}
+	p.items = alive
+}
+
+// Draw 把视口内同一图集页面的粒子合并成一次DrawTriangles
+func (p *Particles) Draw(screen *ebiten.Image) {
+	for page, img := range p.game.atlas.Pages {
+		p.drawPage(screen, page, img)
+	}
+}
+
+func (p *Particles) drawPage(screen *ebiten.Image, page int, img *ebiten.Image) {
+	cam := p.game.camera
+	p.vertices, p.indices = p.vertices[:0], p.indices[:0]
+	for i := range p.items {
+		pt := &p.items[i]
+		if pt.info.Page != page {
+			continue
+		}
+		hw, hh := float64(pt.info.Width)*pt.Scale/2, float64(pt.info.Height)*pt.Scale/2
+		// 裁剪过的精灵，页面中的区域相对原图中心的偏移
+		w, h := pt.info.Size()
+		ox := float64(pt.info.OffsetX*2+pt.info.Width-w) * pt.Scale / 2
+		oy := float64(pt.info.OffsetY*2+pt.info.Height-h) * pt.Scale / 2
+		radius := math.Max(float64(w), float64(h)) * pt.Scale / 2
+		if !cam.InView(pt.X-radius, pt.Y-radius, radius*2, radius*2) {
+			continue
+		}
+		x, y := cam.ToScreen(pt.X, pt.Y)
+		sin, cos := math.Sincos(pt.A)
+		fade := pt.Alpha * float32(pt.Life) / float32(pt.MaxLife)
+		base := uint16(len(p.vertices))
+		for _, corner := range [4][2]float64{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			dx, dy := ox+corner[0]*hw, oy+corner[1]*hh
+			p.vertices = append(p.vertices, ebiten.Vertex{
+				DstX:   float32(x + dx*cos - dy*sin),
+				DstY:   float32(y + dx*sin + dy*cos),
+				SrcX:   float32(pt.info.X) + float32(corner[0]+1)/2*float32(pt.info.Width),
+				SrcY:   float32(pt.info.Y) + float32(corner[1]+1)/2*float32(pt.info.Height),
+				ColorR: pt.R * fade,
+				ColorG: pt.G * fade,
+				ColorB: pt.B * fade,
+				ColorA: fade,
+			})
+		}
+		p.indices = append(p.indices, base, base+1, base+2, base+1, base+3, base+2)
+	}
+	if len(p.indices) > 0 {
+		drawTriangles(screen, p.vertices, p.indices, img, &ebiten.DrawTrianglesOptions{})
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"math"
+)
+
+const DefaultScoreMode = "classic"
+
+// ScoreRules 计分规则，在选项中切换，重开后生效
+type ScoreRules struct {
+	Name          string  `json:"name"`
+	Label         string  `json:"label"`
+	Hit           int     `json:"hit,omitempty"`           // 击中得分，乘以敌人定义的分值
+	Kill          int     `json:"kill,omitempty"`          // 击毁得分，乘以敌人定义的分值
+	Intercept     int     `json:"intercept,omitempty"`     // 拦截敌方子弹得分
+	ComboWindow   int     `json:"comboWindow,omitempty"`   // 连击的时间窗口帧数
+	ComboStep     float64 `json:"comboStep,omitempty"`     // 每次连击增加的倍率
+	MaxMultiplier float64 `json:"maxMultiplier,omitempty"` // 倍率上限
+	NoDamageBonus int     `json:"noDamageBonus,omitempty"` // 一波内未受伤的奖励
+	AccuracyBonus int     `json:"accuracyBonus,omitempty"` // 结算时乘以命中率的奖励
+}
+
+var ScoreModes = []ScoreRules{
+	{Name: "classic", Label: "经典", Hit: 1, Kill: 5, Intercept: 2, ComboWindow: 120, ComboStep: 0.5,
+		MaxMultiplier: 4, NoDamageBonus: 100, AccuracyBonus: 200},
+	{Name: "hardcore", Label: "硬核", Hit: 1, Kill: 8, Intercept: 1, ComboWindow: 90, ComboStep: 0.25,
+		MaxMultiplier: 3, NoDamageBonus: 300, AccuracyBonus: 500},
+}
+
+// ScoreRulesByName 按名称查找计分规则，找不到时使用经典规则
+func ScoreRulesByName(name string) ScoreRules {
+	for _, rules := range ScoreModes {
+		if rules.Name == name {
+			return rules
+		}
+	}
+	return ScoreModes[0]
+}
+
+// ScorePoints 各项得分，用于结算明细
+type ScorePoints struct {
+	Hit       int
+	Kill      int
+	Intercept int
+	Combo     int // 连击倍率带来的额外得分
+	NoDamage  int
+	Accuracy  int
+	Objective int // 完成游戏目标的得分
+}
+
+// Scoring 计分，按规则累计得分、连击倍率和奖励，回合结束时生成得分明细
+type Scoring struct {
+	game       *Game
+	rules      ScoreRules
+	combo      int // 当前连击数
+	comboTimer int // 连击剩余的帧数
+	shots      int
+	hits       int
+	kills      int
+	intercepts int
+	damaged    bool // 本波是否受伤
+	points     ScorePoints
+	breakdown  []string
+}
+
+func NewScoring(g *Game, bus *EventBus) *Scoring {
+	s := &Scoring{game: g, rules: ScoreRulesByName(g.mode)}
+	Subscribe(bus, func(e BulletFired) {
+		if e.Tank == g.hero.Tank {
+			s.Shot()
+		}
+	})
+	Subscribe(bus, func(e TankHit) {
+		if e.Tank == g.hero.Tank {
+			s.HeroDamaged()
+		} else if e.Bullet.tank == g.hero.Tank && g.hostile(g.hero.id, e.Tank.id) {
+			s.Hit(e.Tank.def)
+		}
+	})
+	Subscribe(bus, func(e TankDestroyed) {
+		if e.Bullet.tank == g.hero.Tank && g.hostile(g.hero.id, e.Tank.id) {
+			s.Kill(e.Tank.def)
+		}
+	})
+	Subscribe(bus, func(e BulletIntercepted) {
+		if e.Bullet.tank == g.hero.Tank || e.Other.tank == g.hero.Tank {
+			s.Intercept()
+		}
+	})
+	Subscribe(bus, func(e ObjectiveScored) {
+		if e.Team == g.world.teams[g.hero.id] {
+			s.add(&s.points.Objective, e.Points, false)
+		}
+	})
+	Subscribe(bus, func(WaveCleared) {
+		s.WaveCleared()
+	})
+	Subscribe(bus, func(Restarted) {
+		s.Reset()
+	})
+	return s
+}
+
+// Reset 开始新的一局，使用本局的计分规则
+func (s *Scoring) Reset() {
+	s.rules = ScoreRulesByName(s.game.mode)
+	s.combo, s.comboTimer = 0, 0
+	s.shots, s.hits, s.kills, s.intercepts = 0, 0, 0, 0
+	s.damaged = false
+	s.points = ScorePoints{}
+	s.breakdown = nil
+}
+
+// Multiplier 当前的连击倍率
+func (s *Scoring) Multiplier() float64 {
+	if s.combo < 2 {
+		return 1
+	}
+	return math.Min(1+float64(s.combo-1)*s.rules.ComboStep, s.rules.MaxMultiplier)
+}
+
+func (s *Scoring) Update() {
+	if s.comboTimer > 0 {
+		s.comboTimer--
+		if s.comboTimer == 0 {
+			s.combo = 0
+		}
+	}
+}
+
+func (s *Scoring) Shot() {
+	s.shots++
+}
+
+// Hit 击中敌人，连击在时间窗口内延续
+func (s *Scoring) Hit(def *TankDef) {
+	s.hits++
+	s.chain()
+	s.add(&s.points.Hit, s.rules.Hit*def.Score, true)
+}
+
+// Kill 击毁敌人额外计分，和本次击中属于同一次连击
+func (s *Scoring) Kill(def *TankDef) {
+	s.kills++
+	s.add(&s.points.Kill, s.rules.Kill*def.Score, true)
+}
+
+// Intercept 子弹拦截敌方子弹
+func (s *Scoring) Intercept() {
+	s.intercepts++
+	s.chain()
+	s.add(&s.points.Intercept, s.rules.Intercept, true)
+}
+
+// HeroDamaged 英雄受伤，连击中断且本波失去无伤奖励
+func (s *Scoring) HeroDamaged() {
+	s.damaged = true
+	s.combo, s.comboTimer = 0, 0
+}
+
+// WaveCleared 一波结束时结算无伤奖励
+func (s *Scoring) WaveCleared() {
+	if !s.damaged {
+		s.add(&s.points.NoDamage, s.rules.NoDamageBonus, false)
+	}
+	s.damaged = false
+}
+
+// Accuracy 命中率，拦截子弹也算命中
+func (s *Scoring) Accuracy() float64 {
+	if s.shots == 0 {
+		return 0
+	}
+	return math.Min(1, float64(s.hits+s.intercepts)/float64(s.shots))
+}
+
+// Finish 回合结束时结算命中率奖励并生成得分明细
+func (s *Scoring) Finish() {
+	s.add(&s.points.Accuracy, int(float64(s.rules.AccuracyBonus)*s.Accuracy()), false)
+	s.breakdown = []string{
+		fmt.Sprintf("击中 %d 次：%d", s.hits, s.points.Hit),
+		fmt.Sprintf("击毁 %d 辆：%d", s.kills, s.points.Kill),
+		fmt.Sprintf("拦截 %d 发：%d", s.intercepts, s.points.Intercept),
+		fmt.Sprintf("连击加成：%d", s.points.Combo),
+		fmt.Sprintf("无伤奖励：%d", s.points.NoDamage),
+		fmt.Sprintf("命中率 %.0f%%：%d", s.Accuracy()*100, s.points.Accuracy),
+		fmt.Sprintf("目标：%d", s.points.Objective),
+		fmt.Sprintf("总分：%d", s.game.score),
+	}
+	if result := s.game.objective.Result(); result != nil {
+		s.breakdown = append(result, s.breakdown...)
+	}
+}
+
+func (s *Scoring) chain() {
+	if s.comboTimer > 0 {
+		s.combo++
+	} else {
+		s.combo = 1
+	}
+	s.comboTimer = s.rules.ComboWindow
+}
+
+// add 计入得分，连击倍率带来的额外得分单独记为连击加成
+func (s *Scoring) add(item *int, points int, combo bool) {
+	*item += points
+	if combo {
+		extra := int(float64(points)*s.Multiplier()) - points
+		s.points.Combo += extra
+		points += extra
+	}
+	g := s.game
+	g.score += points
+	if g.highScore < g.score {
+		g.highScore = g.score
+	}
+}
+
+// Draw 显示连击倍率，回合结束时显示得分明细
+func (s *Scoring) Draw(screen *ebiten.Image) {
+	g := s.game
+	if s.combo > 1 {
+		drawText(screen, fmt.Sprintf("连击 %d  x%.1f", s.combo, s.Multiplier()),
+			g.chsFont, 3, 68, colornames.Orange)
+	}
+	if s.breakdown == nil {
+		return
+	}
+	w, h := float32(360), float32(60+len(s.breakdown)*28)
+	x, y := (float32(g.width)-w)/2, (float32(g.height)-h)/2
+	fillRect(screen, x, y, w, h, color.RGBA{A: 0xc0}, false)
+	drawText(screen, "本局结算", g.chsFont, int(x)+20, int(y)+32, colornames.Yellow)
+	for i, line := range s.breakdown {
+		drawText(screen, line, g.chsFont, int(x)+20, int(y)+64+i*28, g.skin.Palette.Text)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const SettingsFile = "settings.json"
+
+// Settings 玩家的设置，保存在用户配置目录
+type Settings struct {
+	MasterVolume float64 `json:"masterVolume"`
+	MusicVolume  float64 `json:"musicVolume"`
+	SFXVolume    float64 `json:"sfxVolume"`
+	Muted        bool    `json:"muted"`
+	Skin         string  `json:"skin,omitempty"`
+	Teams        string  `json:"teams,omitempty"`     // 阵营设置的名称
+	Objective    string  `json:"objective,omitempty"` // 游戏目标的名称
+	Scoring      string  `json:"scoring,omitempty"`   // 计分规则的名称
+	Wingmen      int     `json:"wingmen"`
+	FriendlyFire bool    `json:"friendlyFire"`
+}
+
+// LoadSettings 读取设置文件，不存在时使用默认设置
+func LoadSettings() *Settings {
+	settings := &Settings{MasterVolume: 1, MusicVolume: 0.2, SFXVolume: 0.5, Teams: DefaultTeams,
+		Objective: DefaultObjective, Scoring: DefaultScoreMode}
+	data, err := os.ReadFile(configPath(SettingsFile))
+	if err == nil {
+		err = json.Unmarshal(data, settings)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("load settings:", err)
+	}
+	return settings
+}
+
+func (s *Settings) Save() {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = writeConfig(SettingsFile, data)
+	}
+	if err != nil {
+		log.Println("save settings:", err)
+	}
+}
+
+// configPath 配置文件的路径，取不到用户配置目录时使用工作目录
+func configPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(dir, "go-tank", name)
+}
+
+func writeConfig(name string, data []byte) error {
+	path := configPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
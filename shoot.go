@@ -2,107 +2,197 @@ package main
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	"math"
 	"math/rand"
 )
 
+// Weapon 武器属性，决定子弹的伤害、反弹和穿透能力
+type Weapon struct {
+	Damage  float64 `json:"damage,omitempty"`
+	Bounces int     `json:"bounces,omitempty"` // 遇到金属障碍物和世界边缘时最多反弹的次数
+	Pierce  int     `json:"pierce,omitempty"`  // 最多穿透的非金属障碍物个数
+}
+
 type Bullet struct {
 	*BoxSprite
-	game  *Game
-	speed float64
-	tank  *Tank
-	next  *Bullet
+	id      Entity
+	game    *Game
+	speed   float64
+	tank    *Tank
+	alive   bool
+	damage  float64 // 剩余伤害，穿透障碍物时减少
+	bounces int     // 剩余反弹次数
+	pierce  int     // 剩余穿透次数
+	inside  Entity  // 正在穿透的障碍物
 }
 
-func (b *Bullet) AutoMove() {
-	if b.A == AngleZero {
-		b.Y = b.Y - b.speed
+func (b *Bullet) Draw(screen *ebiten.Image) {
+	if b.game.fog.Visible(b.BoxSprite) {
+		b.BoxSprite.Draw(screen, b.game.camera)
 	}
-	if b.A == AnglePi {
-		b.Y = b.Y + b.speed
+}
+
+// AutoMove 沿角度方向移动，角度为0时向上
+func (b *Bullet) AutoMove() {
+	sin, cos := math.Sincos(b.A)
+	b.X = b.X + sin*b.speed
+	b.Y = b.Y - cos*b.speed
+}
+
+func (b *Bullet) HitCheck() {
+	if !b.alive {
+		return
 	}
-	if b.A == AngleTrebleHalfPi {
-		b.X = b.X - b.speed
+	if b.hitTargets() {
+		return
 	}
-	if b.A == AngleHalfPi {
-		b.X = b.X + b.speed
+	if !b.hitObstacles() {
+		b.hitEdges()
 	}
 }
 
-func (b *Bullet) HitCheck() {
-	// 子弹是否与敌方坦克碰撞
-	if b.tank == b.game.hero.Tank {
-		for other := b.game.enemy; other != nil; other = other.Next {
-			if b.hitTank(other.Value.Tank) || b.hitBullets(other.Value.Tank.bullet) {
-				b.game.score += int(other.Value.speed)
-				if b.game.highScore < b.game.score {
-					b.game.highScore = b.game.score
-				}
-				return
-			}
+// hitTargets 子弹是否与敌对阵营的子弹或有生命的实体碰撞，不敌对的直接穿过，
+// 开启友军伤害时也会击中队友
+func (b *Bullet) hitTargets() bool {
+	w := b.game.world
+	return Find(w, w.colliders, func(id Entity, collider *Collider) bool {
+		if collider.Static || !b.game.canHit(b, id) {
+			return false
 		}
-	} else {
-		if b.hitTank(b.game.hero.Tank) || b.hitBullets(b.game.hero.Tank.bullet) {
-			return
+		if bullet, ok := w.bullets[id]; ok {
+			return b.hitBullet(bullet)
 		}
-	}
-
-	b.hitTrees()
+		if health, ok := w.healths[id]; ok {
+			return b.hitHealth(id, health)
+		}
+		return false
+	}) != 0
 }
 
-func (b *Bullet) hitTrees() bool {
-	// 子弹是否与树碰撞
-	for tree := b.game.ground.trees; tree != nil; tree = tree.Next {
-		if cx, cy := b.CollideXY(tree.Value); cx != 0 && cy != 0 {
-			b.X = -1000 // 子弹失效
+func (b *Bullet) hitObstacles() bool {
+	// 子弹是否与障碍物碰撞
+	w := b.game.world
+	return Find(w, w.colliders, func(id Entity, obstacle *Collider) bool {
+		if !obstacle.Static {
+			return false
+		}
+		cx, cy := b.CollideXY(w.transforms[id])
+		if cx == 0 || cy == 0 {
+			if b.inside == id {
+				b.inside = 0 // 已穿出
+			}
+			return false
+		}
+		if b.inside == id {
+			return false
+		}
+		if obstacle.Metal {
+			if b.bounces > 0 {
+				b.bounce(cx, cy)
+			} else {
+				b.alive = false
+			}
 			return true
 		}
+		if b.pierce > 0 && b.damage > obstacle.Resist {
+			b.pierce--
+			b.damage -= obstacle.Resist
+			b.inside = id
+			return false
+		}
+		b.alive = false
+		return true
+	}) != 0
+}
+
+func (b *Bullet) hitEdges() bool {
+	// 子弹到达世界边缘时反弹或失效
+	w, h := b.GetDrawWH()
+	var cx, cy float64
+	if b.X < 0 {
+		cx = -b.X
+	} else if over := b.X + w - float64(b.game.worldWidth); over > 0 {
+		cx = -over
 	}
-	return false
+	if b.Y < 0 {
+		cy = -b.Y
+	} else if over := b.Y + h - float64(b.game.worldHeight); over > 0 {
+		cy = -over
+	}
+	if cx == 0 && cy == 0 {
+		return false
+	}
+	if b.bounces > 0 {
+		b.bounce(cx, cy)
+	} else {
+		b.alive = false
+	}
+	return true
 }
 
-func (b *Bullet) hitTank(other *Tank) bool {
-	// 是否击中敌方坦克
-	if cx, cy := b.CollideXY(other.BoxSprite); cx != 0 && cy != 0 {
-		if other.life > 0 { // 活着的坦克才能被击中
-			if other.hitStatus < 1 { // 坦克未受攻击保护
-				other.life--
-				if other.life < 1 {
-					other.hitStatus = DieHitStatus
-					_ = other.game.explodeAudio.Rewind()
-					other.game.explodeAudio.Play()
-				} else {
-					other.hitStatus = other.hitProtect
-					_ = other.game.hitAudio.Rewind()
-					other.game.hitAudio.Play()
-				}
-			}
-			b.X = -1000 // 子弹失效
-			return true
-		}
+// bounce 沿重叠较小的轴反射方向并移出碰撞，重叠为0表示该轴没有碰撞
+func (b *Bullet) bounce(cx, cy float64) {
+	b.bounces--
+	sin, cos := math.Sincos(b.A)
+	dx, dy := sin, -cos
+	if cy == 0 || (cx != 0 && math.Abs(cx) < math.Abs(cy)) {
+		dx = -dx
+		b.X = b.X + cx
+	} else {
+		dy = -dy
+		b.Y = b.Y + cy
 	}
-	return false
+	b.A = math.Mod(math.Atan2(dx, -dy)+math.Pi*2, math.Pi*2)
 }
 
-func (b *Bullet) hitBullets(bullet *Bullet) bool {
-	// 子弹是否与敌方子弹碰撞
-	for ; bullet != nil; bullet = bullet.next {
-		if cx, cy := b.CollideXY(bullet.BoxSprite); cx != 0 && cy != 0 {
-			b.X = -1000      // 子弹失效
-			bullet.X = -1000 // 子弹失效
-			return true
+// hitHealth 是否击中有生命的实体，受击保护期间不受伤害，击中坦克时发出坦克的受击事件
+func (b *Bullet) hitHealth(id Entity, health *Health) bool {
+	w := b.game.world
+	box := w.transforms[id]
+	if cx, cy := b.CollideXY(box); cx == 0 || cy == 0 || health.life <= 0 {
+		return false // 活着的实体才能被击中
+	}
+	b.alive = false
+	if health.hitStatus > 0 {
+		return true
+	}
+	damage, crit := health.TakeDamage(b)
+	health.life -= damage
+	if health.life > 0 {
+		health.hitStatus = health.hitProtect
+	}
+	tank := w.tanks[id]
+	if tank != nil {
+		Emit(b.game.events, TankHit{Tank: tank, Bullet: b, Damage: damage, Crit: crit})
+	}
+	if health.life <= 0 {
+		health.die(b.game, box)
+		if tank != nil {
+			Emit(b.game.events, TankDestroyed{Tank: tank, Bullet: b})
 		}
 	}
+	return true
+}
+
+// hitBullet 子弹是否与敌方子弹碰撞
+func (b *Bullet) hitBullet(bullet *Bullet) bool {
+	if !bullet.alive {
+		return false
+	}
+	if cx, cy := b.CollideXY(bullet.BoxSprite); cx != 0 && cy != 0 {
+		b.alive = false
+		bullet.alive = false
+		Emit(b.game.events, BulletIntercepted{Bullet: b, Other: bullet})
+		return true
+	}
 	return false
 }
 
 func (h *Hero) UpdateShoot() {
-	h.UpdateBullet()
-	if !h.checkHealth() {
+	if h.life <= 0 || !h.Ready() {
 		return
 	}
-	if h.shootCool < ShootCooled {
-		h.shootCool += h.shootCoolDown
-	} else if ebiten.IsKeyPressed(ebiten.KeyEnter) || ebiten.IsKeyPressed(ebiten.KeyControl) ||
+	if ebiten.IsKeyPressed(ebiten.KeyEnter) || ebiten.IsKeyPressed(ebiten.KeyControl) ||
 		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonFrontBottomLeft) ||
 		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonFrontBottomRight) ||
 		ebiten.IsStandardGamepadButtonPressed(GamepadID, ebiten.StandardGamepadButtonRightTop) ||
@@ -113,69 +203,39 @@ func (h *Hero) UpdateShoot() {
 	}
 }
 
-func (h *Hero) checkHealth() bool {
-	if h.hitStatus > 0 {
-		h.hitStatus--
-		if h.hitStatus == 0 && h.life < 1 {
-			h.game.Restart()
-			return false
-		}
-	}
-	return true
-}
-
-func (tk *Tank) UpdateBullet() {
-	// 分多次移动避免跳过碰撞
-	for i := 0; i < 4; i++ {
-		var preBullet *Bullet
-		for bullet := tk.bullet; bullet != nil; bullet = bullet.next {
-			bullet.AutoMove()
-			bullet.HitCheck()
-			preBullet = tk.removeInvalidBullet(preBullet, bullet)
-		}
-	}
-}
-
 func (e *Enemy) AutoShoot() {
-	e.UpdateBullet()
-	if !e.checkHealth() {
+	if e.life <= 0 || !e.Ready() {
 		return
 	}
-	if e.shootCool < ShootCooled {
-		e.shootCool += e.shootCoolDown
-	} else if e.game.updates%(1+rand.Intn(120)) == 0 {
-		e.bulletSpeed = BulletSpeeds[e.typ] * (1 + float64(e.game.score)/1000)
+	if e.game.updates%(1+rand.Intn(max(e.def.AI.FireRate, 1))) == 0 && e.canShoot() {
+		e.bulletSpeed = e.def.BulletSpeed * e.game.objective.Pace()
 		e.shootBullet()
 	}
 }
 
-func (e *Enemy) checkHealth() bool {
-	if e.hitStatus > 0 {
-		e.hitStatus--
-		if e.hitStatus == 0 && e.life < 1 {
-			e.reborn()
-			return false
-		}
+// canShoot 中立的坦克不射击，开启迷雾时只有看得到敌对坦克才射击
+func (e *Enemy) canShoot() bool {
+	if e.game.hostility.Peaceful(e.game.world.teams[e.id]) {
+		return false
 	}
-	return true
+	return !e.game.fog.enabled || e.nearestHostile() != nil
 }
 
-func (e *Enemy) reborn() {
-	// 重生在随机位置且不碰撞
-	e.life = e.maxLife
-	e.shootCool = -180
-	minX, minY, maxX, maxY := float64(1), float64(1), float64(1), float64(1)
-	for minX != 0 || minY != 0 || maxX != 0 || maxY != 0 {
-		e.X = e.W + float64(rand.Intn(e.game.width-int(e.W)*2))
-		e.Y = e.H + float64(rand.Intn(e.game.height-int(e.H*2)))
-		minX, minY, maxX, maxY = e.CollideOthers()
-	}
+// reborn 在出生点满血重生，重生后同样有受击保护
+func (tk *Tank) reborn(x, y float64) {
+	tk.X, tk.Y = x, y
+	tk.life = tk.maxLife
+	tk.hitStatus = tk.hitProtect
+	tk.moveStun, tk.fireStun = 0, 0
+	tk.slideX, tk.slideY = 0, 0
+	tk.shootCool = -180
 }
 
 func (tk *Tank) shootBullet() {
 	tk.shootCool = 0
-	img := GetSpriteImage(tk.game.spriteImages, tk.game.spritesInfos[BulletNames[tk.typ]])
-	tk.bullet = &Bullet{
+	info := tk.game.sprite(tk.def.Bullet)
+	img := tk.game.atlas.Image(info)
+	bullet := &Bullet{
 		BoxSprite: &BoxSprite{
 			Img: img,
 			A:   tk.A,
@@ -184,43 +244,46 @@ func (tk *Tank) shootBullet() {
 			W:   float64(img.Bounds().Dx()) * tk.bulletSize,
 			H:   float64(img.Bounds().Dy()) * tk.bulletSize,
 		},
-		game:  tk.game,
-		speed: tk.bulletSpeed / 4,
-		tank:  tk,
-		next:  tk.bullet,
+		game:    tk.game,
+		speed:   tk.bulletSpeed / 4,
+		tank:    tk,
+		alive:   true,
+		damage:  tk.weapon.Damage,
+		bounces: tk.weapon.Bounces,
+		pierce:  tk.weapon.Pierce,
 	}
+	bullet.SetPivot(info)
 
 	// 调整子弹的初始角度和位置
 	w, h := tk.GetDrawWH()
 	if tk.A == AngleZero {
-		tk.bullet.A = AnglePi
-		tk.bullet.X += w/2 - tk.bullet.W/2
-		tk.bullet.Y += h
+		bullet.A = AnglePi
+		bullet.X += w/2 - bullet.W/2
+		bullet.Y += h
 	} else if tk.A == AnglePi {
-		tk.bullet.A = AngleZero
-		tk.bullet.X += w/2 - tk.bullet.W/2
-		tk.bullet.Y -= tk.bullet.H
+		bullet.A = AngleZero
+		bullet.X += w/2 - bullet.W/2
+		bullet.Y -= bullet.H
 	} else if tk.A == AngleHalfPi {
-		tk.bullet.A = AngleTrebleHalfPi
-		tk.bullet.X -= tk.bullet.H
-		tk.bullet.Y += h/2 - tk.bullet.W/2
+		bullet.A = AngleTrebleHalfPi
+		bullet.X -= bullet.H
+		bullet.Y += h/2 - bullet.W/2
 	} else if tk.A == AngleTrebleHalfPi {
-		tk.bullet.A = AngleHalfPi
-		tk.bullet.X += w
-		tk.bullet.Y += h/2 - tk.bullet.W/2
+		bullet.A = AngleHalfPi
+		bullet.X += w
+		bullet.Y += h/2 - bullet.W/2
 	}
+	tk.game.addBullet(bullet)
+	Emit(tk.game.events, BulletFired{Tank: tk, Bullet: bullet})
 }
 
-func (tk *Tank) removeInvalidBullet(preBullet *Bullet, bullet *Bullet) *Bullet {
-	if bullet.X < 0 || bullet.Y < 0 ||
-		bullet.X > float64(tk.game.width) || bullet.Y > float64(tk.game.height) {
-		if preBullet == nil {
-			tk.bullet = bullet.next
-		} else {
-			preBullet.next = bullet.next
-		}
-		return preBullet
-	} else {
-		return bullet
-	}
+// addBullet 把子弹加入世界，子弹属于发射者的阵营
+func (g *Game) addBullet(b *Bullet) {
+	w := g.world
+	b.id = w.Create()
+	w.transforms[b.id] = b.BoxSprite
+	w.sprites[b.id] = Sprite{Layer: LayerBullet, Draw: b.Draw}
+	w.colliders[b.id] = &Collider{}
+	w.teams[b.id] = w.teams[b.tank.id]
+	w.bullets[b.id] = b
 }
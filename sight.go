@@ -0,0 +1,40 @@
+package main
+
+import "math"
+
+// InSight 两个精灵中心之间的视线是否未被障碍物遮挡
+func (g *Game) InSight(from, to *BoxSprite) bool {
+	w1, h1 := from.GetDrawWH()
+	w2, h2 := to.GetDrawWH()
+	x1, y1 := from.X+w1/2, from.Y+h1/2
+	x2, y2 := to.X+w2/2, to.Y+h2/2
+	blocked := false
+	g.eachObstacle(func(_ Entity, obstacle *BoxSprite) {
+		blocked = blocked || segmentHitsBox(x1, y1, x2, y2, obstacle)
+	})
+	return !blocked
+}
+
+// segmentHitsBox 线段是否穿过矩形，使用Liang-Barsky裁剪算法
+func segmentHitsBox(x1, y1, x2, y2 float64, box *BoxSprite) bool {
+	w, h := box.GetDrawWH()
+	tMin, tMax := 0.0, 1.0
+	for _, axis := range [2][4]float64{{x1, x2 - x1, box.X, box.X + w}, {y1, y2 - y1, box.Y, box.Y + h}} {
+		start, delta, low, high := axis[0], axis[1], axis[2], axis[3]
+		if math.Abs(delta) < Precision {
+			if start < low || start > high {
+				return false
+			}
+			continue
+		}
+		t1, t2 := (low-start)/delta, (high-start)/delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin, tMax = math.Max(tMin, t1), math.Min(tMax, t2)
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
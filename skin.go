@@ -0,0 +1,285 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font/opentype"
+	"image"
+	"image/color"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	SkinDir      = "skins" // 工作目录下的皮肤目录，每个皮肤是一个子目录或zip文件
+	SkinManifest = "skin.json"
+	DefaultSkin  = "default"
+)
+
+// SkinSounds 皮肤可以替换的音效
+var SkinSounds = []string{"hit", "explode"}
+
+// Palette 界面的配色
+type Palette struct {
+	Text color.RGBA
+	Life []color.RGBA // 生命条从低到高的颜色
+}
+
+var DefaultPalette = Palette{Text: colornames.Aliceblue, Life: LifeColors}
+
+// SkinDef 皮肤的描述文件，省略的资源使用约定的文件名，找不到时使用内置资源
+type SkinDef struct {
+	Name    string            `json:"name,omitempty"`
+	Atlas   string            `json:"atlas,omitempty"`
+	Font    string            `json:"font,omitempty"`
+	Sounds  map[string]string `json:"sounds,omitempty"`
+	Palette struct {
+		Text string   `json:"text,omitempty"`
+		Life []string `json:"life,omitempty"`
+	} `json:"palette"`
+}
+
+// Skin 加载好的皮肤资源
+type Skin struct {
+	Name    string
+	Sheet   *SpriteSheet
+	Pages   int // 皮肤自己的图集页数，之后的页面是补齐用的内置图集
+	Font    []byte
+	Sounds  map[string]SkinSound
+	Palette Palette
+}
+
+type SkinSound struct {
+	File string // 用扩展名判断音频格式
+	Data []byte
+}
+
+// ListSkins 内置皮肤和皮肤目录下所有的皮肤
+func ListSkins() []string {
+	skins := []string{DefaultSkin}
+	entries, _ := os.ReadDir(SkinDir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			skins = append(skins, name)
+		} else if strings.EqualFold(filepath.Ext(name), ".zip") {
+			skins = append(skins, strings.TrimSuffix(name, filepath.Ext(name)))
+		}
+	}
+	sort.Strings(skins[1:])
+	return skins
+}
+
+// openSkin 打开皮肤目录或zip文件，zip中只有一个目录时使用这个目录
+func openSkin(name string) (fs.FS, func(), error) {
+	dir := filepath.Join(SkinDir, name)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return os.DirFS(dir), func() {}, nil
+	}
+	reader, err := zip.OpenReader(dir + ".zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	var fsys fs.FS = reader
+	if entries, err := fs.ReadDir(reader, "."); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		fsys, _ = fs.Sub(reader, entries[0].Name())
+	}
+	return fsys, func() { _ = reader.Close() }, nil
+}
+
+// DefaultSkinAssets 内置的皮肤
+func DefaultSkinAssets() *Skin {
+	sheet, err := LoadSpriteSheet(assets, DefaultAtlas)
+	FatalIfError(err)
+	skin := &Skin{Name: DefaultSkin, Sheet: sheet, Sounds: map[string]SkinSound{}, Palette: DefaultPalette}
+	skin.Font, err = assets.ReadFile("chsfont.ttf")
+	FatalIfError(err)
+	for _, sound := range SkinSounds {
+		data, err := assets.ReadFile(sound + ".ogg")
+		FatalIfError(err)
+		skin.Sounds[sound] = SkinSound{File: sound + ".ogg", Data: data}
+	}
+	return skin
+}
+
+// LoadSkin 加载皮肤，每项资源单独检查，缺失或无效时使用内置资源，
+// 图集中缺少的精灵也从内置图集补齐
+func LoadSkin(name string) *Skin {
+	skin := DefaultSkinAssets()
+	if name == "" || name == DefaultSkin {
+		return skin
+	}
+	fsys, closeSkin, err := openSkin(name)
+	if err != nil {
+		log.Printf("skin %s: %v, using default skin", name, err)
+		return skin
+	}
+	defer closeSkin()
+	skin.Name = name
+
+	def := SkinDef{Atlas: "sprites.xml", Font: "font.ttf"}
+	if data, err := fs.ReadFile(fsys, SkinManifest); err == nil {
+		if err = json.Unmarshal(data, &def); err != nil {
+			log.Printf("skin %s: %s: %v", name, SkinManifest, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("skin %s: %v", name, err)
+	}
+
+	if sheet, err := LoadSpriteSheet(fsys, def.Atlas); err == nil {
+		skin.Sheet = MergeSpriteSheets(sheet, skin.Sheet)
+		skin.Pages = len(sheet.Pages)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("skin %s: %v, using default sprites", name, err)
+	}
+	if data, err := fs.ReadFile(fsys, def.Font); err == nil {
+		if _, err = opentype.Parse(data); err == nil {
+			skin.Font = data
+		} else {
+			log.Printf("skin %s: %s: %v, using default font", name, def.Font, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("skin %s: %v, using default font", name, err)
+	}
+	for _, sound := range SkinSounds {
+		file, ok := def.Sounds[sound]
+		if !ok {
+			file = findSound(fsys, sound)
+		}
+		if file == "" {
+			continue // 皮肤没有这个音效
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err == nil {
+			err = checkSound(file, data)
+		}
+		if err == nil {
+			skin.Sounds[sound] = SkinSound{File: file, Data: data}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("skin %s: %s: %v, using default sound", name, file, err)
+		}
+	}
+	if clr, err := parseColor(def.Palette.Text); err == nil {
+		skin.Palette.Text = clr
+	} else if def.Palette.Text != "" {
+		log.Printf("skin %s: palette text: %v", name, err)
+	}
+	var life []color.RGBA
+	for _, hex := range def.Palette.Life {
+		clr, err := parseColor(hex)
+		if err != nil {
+			log.Printf("skin %s: palette life: %v", name, err)
+			life = nil
+			break
+		}
+		life = append(life, clr)
+	}
+	if len(life) > 0 {
+		skin.Palette.Life = life
+	}
+	return skin
+}
+
+// checkSound 完整解码一遍音效，格式不支持或数据损坏时返回错误
+func checkSound(file string, data []byte) error {
+	stream, _, err := decodeSound(file, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, stream)
+	return err
+}
+
+// findSound 按约定的文件名查找音效，支持的格式都可以
+func findSound(fsys fs.FS, sound string) string {
+	for _, ext := range []string{".ogg", ".mp3", ".wav"} {
+		if _, err := fs.Stat(fsys, sound+ext); err == nil {
+			return sound + ext
+		}
+	}
+	return ""
+}
+
+// parseColor 解析#rrggbb或#rrggbbaa格式的颜色
+func parseColor(hex string) (color.RGBA, error) {
+	s := strings.TrimPrefix(hex, "#")
+	if len(s) == 6 {
+		s += "ff"
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", hex)
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// MergeSpriteSheets 合并两个图集，第一个图集中没有的精灵从第二个图集补齐
+func MergeSpriteSheets(sheet, fallback *SpriteSheet) *SpriteSheet {
+	merged := &SpriteSheet{
+		Pages:     append(append([]image.Image{}, sheet.Pages...), fallback.Pages...),
+		PagePaths: append(append([]string{}, sheet.PagePaths...), fallback.PagePaths...),
+		Sprites:   append([]SpriteInfo{}, sheet.Sprites...),
+	}
+	names := make(map[string]bool, len(sheet.Sprites))
+	for _, sprite := range sheet.Sprites {
+		names[sprite.Name] = true
+	}
+	for _, sprite := range fallback.Sprites {
+		if !names[sprite.Name] {
+			sprite.Page += len(sheet.Pages)
+			merged.Sprites = append(merged.Sprites, sprite)
+		}
+	}
+	sort.Slice(merged.Sprites, func(i, j int) bool {
+		return merged.Sprites[i].Name < merged.Sprites[j].Name
+	})
+	return merged
+}
+
+// requiredSprites 游戏用到的所有精灵名称
+func (g *Game) requiredSprites() []string {
+	names := []string{"treeGreen_large", "treeBrown_large", "crateMetal", "barricadeMetal",
+		"oilSpill_large", "oilSpill_small", "shotThin"}
+	for _, def := range AnimationDefs {
+		for _, frame := range def.Frames {
+			names = append(names, frame.Sprite)
+		}
+	}
+	for _, terrain := range g.level.Terrains {
+		names = append(names, terrain.Sprite)
+		if terrain.Overlay != "" {
+			names = append(names, terrain.Overlay)
+		}
+	}
+	for _, def := range g.tankDefs.Tanks {
+		names = append(names, def.Sprite, def.Tracks, def.Bullet)
+	}
+	return names
+}
+
+// checkSkin 检查皮肤是否包含游戏需要的所有精灵，缺少的已经使用内置精灵
+func (g *Game) checkSkin(skin *Skin) {
+	if skin.Name == DefaultSkin {
+		return
+	}
+	var missing []string
+	for _, name := range g.requiredSprites() {
+		if info, err := g.atlas.Sprite(name); err == nil && info.Page >= skin.Pages {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("skin %s: missing %d sprites, using default: %s",
+			skin.Name, len(missing), strings.Join(missing, ", "))
+	}
+}
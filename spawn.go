@@ -0,0 +1,216 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"math"
+	"math/rand"
+)
+
+const (
+	MinSpawnDistance = 500 // 出生点与玩家的最小距离
+	SpawnBackoff     = 30  // 出生点全被占用时首次等待的帧数
+	MaxSpawnBackoff  = 240 // 等待帧数翻倍的上限
+)
+
+// SpawnWave 一波敌人的重生节奏
+type SpawnWave struct {
+	Kills       int `json:"kills,omitempty"`       // 本波需要击毁的数量
+	RebornDelay int `json:"rebornDelay,omitempty"` // 死亡后排队等待的帧数
+	WarnTime    int `json:"warnTime,omitempty"`    // 出生前预警的帧数
+}
+
+// spawnTicket 排队等待出生的坦克
+type spawnTicket struct {
+	tank  *Tank
+	wait  int // 剩余等待帧数，小于1时才分配出生点
+	point int // 预警中的出生点下标，-1表示尚未分配
+	warn  int // 剩余预警帧数
+	total int // 预警的总帧数
+}
+
+// Spawner 出生管理，坦克在本阵营的空闲出生点预警后出生，敌对坦克还要远离玩家，
+// 出生点全被占用时退避重试
+type Spawner struct {
+	game    *Game
+	tickets []*spawnTicket
+	wave    int
+	kills   int // 本波已击毁的数量
+	backoff int // 当前退避的帧数
+	retry   int // 距离下次重试的帧数
+}
+
+func NewSpawner(g *Game) *Spawner {
+	return &Spawner{game: g}
+}
+
+func (s *Spawner) Reset() {
+	s.tickets = s.tickets[:0]
+	s.wave, s.kills, s.backoff, s.retry = 0, 0, 0, 0
+}
+
+// Wave 当前一波的重生节奏，超过最后一波时沿用最后一波
+func (s *Spawner) Wave() SpawnWave {
+	return s.game.level.Waves[min(s.wave, len(s.game.level.Waves)-1)]
+}
+
+// WaveNumber 当前是第几波，从0开始
+func (s *Spawner) WaveNumber() int {
+	return s.wave
+}
+
+// Killed AI坦克死亡后排队重生，与英雄敌对的才计入本波，游戏目标可以关闭按击毁数推进
+func (s *Spawner) Killed(tk *Tank) {
+	if s.game.hostile(s.game.hero.id, tk.id) {
+		s.kills++
+	}
+	wave := s.Wave()
+	if s.game.objective.KillWaves() && wave.Kills > 0 && s.kills >= wave.Kills && s.wave < len(s.game.level.Waves)-1 {
+		s.Advance()
+	}
+	s.Enqueue(tk, s.Wave().RebornDelay)
+}
+
+// Advance 进入下一波，除了击毁足够数量，游戏目标也可以按时间推进
+func (s *Spawner) Advance() {
+	s.wave++
+	s.kills = 0
+	Emit(s.game.events, WaveCleared{Wave: s.wave - 1})
+}
+
+// Enqueue 坦克排队等待出生
+func (s *Spawner) Enqueue(tk *Tank, wait int) {
+	s.tickets = append(s.tickets, &spawnTicket{tank: tk, wait: wait, point: -1})
+}
+
+// points 坦克可用的出生点，游戏目标没有指定时使用关卡的出生点
+func (s *Spawner) points(tk *Tank) [][2]float64 {
+	if points := s.game.objective.SpawnPoints(s.game.world.teams[tk.id]); points != nil {
+		return points
+	}
+	return s.game.level.SpawnPoints
+}
+
+func (s *Spawner) Update() {
+	if s.retry > 0 {
+		s.retry--
+	}
+	remain := s.tickets[:0]
+	for _, ticket := range s.tickets {
+		if !s.advance(ticket) {
+			remain = append(remain, ticket)
+		}
+	}
+	s.tickets = remain
+}
+
+// advance 推进一张出生票，返回是否已经出生
+func (s *Spawner) advance(ticket *spawnTicket) bool {
+	if ticket.wait > 0 {
+		ticket.wait--
+		return false
+	}
+	if ticket.point < 0 {
+		if s.retry > 0 {
+			return false
+		}
+		ticket.point = s.freePoint(ticket.tank)
+		if ticket.point < 0 {
+			// 出生点全被占用，等待时间逐次翻倍
+			s.backoff = min(max(s.backoff*2, SpawnBackoff), MaxSpawnBackoff)
+			s.retry = s.backoff
+			return false
+		}
+		s.backoff = 0
+		ticket.warn = s.Wave().WarnTime
+		ticket.total = max(ticket.warn, 1)
+	}
+	if ticket.warn > 0 {
+		ticket.warn--
+		return false
+	}
+	x, y := s.pointXY(ticket.point, ticket.tank)
+	if !s.available(ticket.tank, x, y) {
+		// 预警期间出生点被占用，重新排队
+		ticket.point = -1
+		return false
+	}
+	ticket.tank.reborn(x, y)
+	return true
+}
+
+// freePoint 随机选择一个空闲的出生点，没有则返回-1
+func (s *Spawner) freePoint(tk *Tank) int {
+	points := s.points(tk)
+	start := rand.Intn(len(points))
+	for i := 0; i < len(points); i++ {
+		point := (start + i) % len(points)
+		if s.reserved(point, tk) {
+			continue
+		}
+		if x, y := s.pointXY(point, tk); s.available(tk, x, y) {
+			return point
+		}
+	}
+	return -1
+}
+
+// reserved 出生点是否已被同一组出生点的其他坦克预定
+func (s *Spawner) reserved(point int, tk *Tank) bool {
+	teams := s.game.world.teams
+	for _, ticket := range s.tickets {
+		if ticket.point == point && (s.game.objective.SpawnPoints(teams[tk.id]) == nil ||
+			teams[ticket.tank.id] == teams[tk.id]) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointXY 出生点对应的坦克左上角坐标，不超出世界
+func (s *Spawner) pointXY(point int, tk *Tank) (float64, float64) {
+	g := s.game
+	pos := s.points(tk)[point]
+	x := float64(g.worldWidth)*pos[0] - tk.W/2
+	y := float64(g.worldHeight)*pos[1] - tk.H/2
+	return math.Max(0, math.Min(x, float64(g.worldWidth)-tk.W)),
+		math.Max(0, math.Min(y, float64(g.worldHeight)-tk.H))
+}
+
+// available 位置是否不与其他物体碰撞，敌对坦克还要远离玩家
+func (s *Spawner) available(tk *Tank, x, y float64) bool {
+	if hero := s.game.hero; hero.life > 0 && s.game.hostile(hero.id, tk.id) &&
+		math.Hypot(hero.X-x, hero.Y-y) < MinSpawnDistance {
+		return false
+	}
+	oldX, oldY := tk.X, tk.Y
+	tk.X, tk.Y = x, y
+	minX, minY, maxX, maxY := tk.CollideOthers()
+	tk.X, tk.Y = oldX, oldY
+	return minX == 0 && minY == 0 && maxX == 0 && maxY == 0
+}
+
+// Draw 在预警中的出生点绘制闪烁的坦克轮廓和收缩的圆圈
+func (s *Spawner) Draw(screen *ebiten.Image) {
+	cam := s.game.camera
+	for _, ticket := range s.tickets {
+		if ticket.point < 0 {
+			continue
+		}
+		e := ticket.tank
+		x, y := s.pointXY(ticket.point, e)
+		if !cam.InView(x, y, e.W, e.H) || !s.game.fog.Visible(&BoxSprite{X: x, Y: y, W: e.W, H: e.H}) {
+			continue
+		}
+		progress := 1 - float64(ticket.warn)/float64(ticket.total)
+		sx, sy := cam.ToScreen(x+e.W/2, y+e.H/2)
+		radius := float32(e.W * (1.2 - progress*0.7))
+		strokeCircle(screen, float32(sx), float32(sy), radius, 3, colornames.Orangered, true)
+
+		options := &ebiten.DrawImageOptions{}
+		options.GeoM.Translate(x, y)
+		cam.Apply(&options.GeoM)
+		options.ColorScale.ScaleAlpha(float32(0.2 + 0.3*math.Abs(math.Sin(float64(ticket.warn)/5))))
+		drawImage(screen, e.Img, options)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"log"
+	"os"
+	"os/user"
+	"time"
+)
+
+const (
+	StatsFile = "stats.json"
+	ToastLife = 180 // 成就提示显示的帧数
+)
+
+// PlayerStats 一个玩家的累计统计
+type PlayerStats struct {
+	Shots           int                  `json:"shots"`
+	Hits            int                  `json:"hits"`
+	Deaths          int                  `json:"deaths"`
+	Kills           map[string]int       `json:"kills"`           // 按坦克定义的名称统计击毁数
+	PlayFrames      int                  `json:"playFrames"`      // 累计游戏的帧数
+	LongestSurvival int                  `json:"longestSurvival"` // 单条命最长存活的帧数
+	MaxWave         int                  `json:"maxWave"`
+	Achievements    map[string]time.Time `json:"achievements"` // 已解锁的成就和解锁时间
+}
+
+func (p *PlayerStats) TotalKills() int {
+	total := 0
+	for _, kills := range p.Kills {
+		total += kills
+	}
+	return total
+}
+
+func (p *PlayerStats) Accuracy() float64 {
+	if p.Shots == 0 {
+		return 0
+	}
+	return float64(p.Hits) / float64(p.Shots)
+}
+
+// Achievement 成就，满足条件时解锁
+type Achievement struct {
+	ID    string
+	Name  string
+	Desc  string
+	Check func(p *PlayerStats) bool
+}
+
+var Achievements = []Achievement{
+	{"first_blood", "首开纪录", "击毁第一辆敌方坦克", func(p *PlayerStats) bool {
+		return p.TotalKills() >= 1
+	}},
+	{"centurion", "百战之师", "累计击毁100辆敌方坦克", func(p *PlayerStats) bool {
+		return p.TotalKills() >= 100
+	}},
+	{"sharpshooter", "神射手", "开火至少100次且命中率达到60%", func(p *PlayerStats) bool {
+		return p.Shots >= 100 && p.Accuracy() >= 0.6
+	}},
+	{"survivor", "幸存者", "一条命坚持5分钟", func(p *PlayerStats) bool {
+		return p.LongestSurvival >= 5*60*ebiten.DefaultTPS
+	}},
+	{"wave_rider", "乘风破浪", "到达第3波", func(p *PlayerStats) bool {
+		return p.MaxWave >= 3
+	}},
+	{"veteran", "老兵", "累计游戏1小时", func(p *PlayerStats) bool {
+		return p.PlayFrames >= 60*60*ebiten.DefaultTPS
+	}},
+}
+
+// StatsStore 所有玩家的统计，保存在用户配置目录
+type StatsStore struct {
+	Players map[string]*PlayerStats `json:"players"`
+}
+
+// LoadStats 读取统计文件，不存在时返回空的统计
+func LoadStats() *StatsStore {
+	store := &StatsStore{Players: map[string]*PlayerStats{}}
+	data, err := os.ReadFile(configPath(StatsFile))
+	if err == nil {
+		err = json.Unmarshal(data, store)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("load stats:", err)
+	}
+	return store
+}
+
+func (s *StatsStore) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeConfig(StatsFile, data)
+}
+
+// Player 取得玩家的统计，没有则创建
+func (s *StatsStore) Player(name string) *PlayerStats {
+	p, ok := s.Players[name]
+	if !ok {
+		p = &PlayerStats{}
+		s.Players[name] = p
+	}
+	if p.Kills == nil {
+		p.Kills = map[string]int{}
+	}
+	if p.Achievements == nil {
+		p.Achievements = map[string]time.Time{}
+	}
+	return p
+}
+
+// PlayerName 玩家名称，优先使用环境变量GO_TANK_PLAYER
+func PlayerName() string {
+	if name := os.Getenv("GO_TANK_PLAYER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "player"
+}
+
+// Stats 订阅游戏事件更新当前玩家的统计，并在成就解锁时提示
+type Stats struct {
+	game     *Game
+	store    *StatsStore
+	player   *PlayerStats
+	survival int // 本条命存活的帧数
+	toasts   []string
+	toast    int // 当前提示剩余的帧数
+}
+
+func NewStats(g *Game, bus *EventBus) *Stats {
+	s := &Stats{game: g, store: LoadStats()}
+	s.player = s.store.Player(PlayerName())
+	Subscribe(bus, func(e BulletFired) {
+		if e.Tank == g.hero.Tank {
+			s.player.Shots++
+		}
+	})
+	Subscribe(bus, func(e TankHit) {
+		if e.Bullet.tank == g.hero.Tank && g.hostile(g.hero.id, e.Tank.id) {
+			s.player.Hits++
+		}
+	})
+	Subscribe(bus, func(e TankDestroyed) {
+		if e.Bullet.tank == g.hero.Tank && g.hostile(g.hero.id, e.Tank.id) {
+			s.player.Kills[e.Tank.def.Name]++
+			s.check()
+		}
+	})
+	Subscribe(bus, func(e HeroDied) {
+		s.player.Deaths++
+		s.endLife()
+		s.Save()
+	})
+	Subscribe(bus, func(e WaveCleared) {
+		s.player.MaxWave = max(s.player.MaxWave, e.Wave+1)
+		s.check()
+	})
+	Subscribe(bus, func(Restarted) {
+		s.Restart()
+	})
+	return s
+}
+
+// Update 累计游戏时间和存活时间，只在未暂停时调用
+func (s *Stats) Update() {
+	s.player.PlayFrames++
+	s.survival++
+	if s.game.updates%ebiten.DefaultTPS == 0 {
+		s.player.LongestSurvival = max(s.player.LongestSurvival, s.survival)
+		s.check()
+	}
+}
+
+// Restart 开始新的一条命
+func (s *Stats) Restart() {
+	s.endLife()
+}
+
+func (s *Stats) endLife() {
+	s.player.LongestSurvival = max(s.player.LongestSurvival, s.survival)
+	s.survival = 0
+	s.check()
+}
+
+func (s *Stats) Save() {
+	if err := s.store.Save(); err != nil {
+		log.Println("save stats:", err)
+	}
+}
+
+// check 检查是否有新解锁的成就
+func (s *Stats) check() {
+	for _, achievement := range Achievements {
+		if _, ok := s.player.Achievements[achievement.ID]; ok || !achievement.Check(s.player) {
+			continue
+		}
+		s.player.Achievements[achievement.ID] = time.Now()
+		s.toasts = append(s.toasts, "成就解锁："+achievement.Name+" - "+achievement.Desc)
+	}
+}
+
+// UpdateToast 依次显示成就解锁的提示，暂停时也继续计时
+func (s *Stats) UpdateToast() {
+	if s.toast > 0 {
+		s.toast--
+		if s.toast == 0 {
+			s.toasts = s.toasts[1:]
+		}
+	} else if len(s.toasts) > 0 {
+		s.toast = ToastLife
+	}
+}
+
+func (s *Stats) Draw(screen *ebiten.Image) {
+	if s.toast == 0 {
+		return
+	}
+	msg := s.toasts[0]
+	g := s.game
+	w := float32(len([]rune(msg))*20 + 40)
+	x := (float32(g.width) - w) / 2
+	fillRect(screen, x, 60, w, 40, color.RGBA{A: 0xc0}, false)
+	drawText(screen, msg, g.chsFont, int(x)+20, 87, colornames.Gold)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"time"
+)
+
+const (
+	SurvivalWaveTime   = 30 * 60 // 每一波持续的帧数
+	SurvivalPace       = 0.15    // 每一波AI加快的比例
+	MaxSurvivalEnemies = 16      // 每一波增援一辆敌人，最多这么多辆
+	LeaderboardShown   = 5       // 结算时显示的排行榜名次
+)
+
+// Survival 生存模式，每隔一段时间进入下一波，敌人增援并加快，
+// 英雄阵亡时按坚持的时间记入排行榜
+type Survival struct {
+	game    *Game
+	elapsed int // 已经坚持的帧数
+	enemies int
+	rank    int
+	done    bool
+}
+
+func NewSurvival(g *Game) *Survival {
+	return &Survival{game: g}
+}
+
+func (s *Survival) Name() string {
+	return "survival"
+}
+
+func (s *Survival) Reset() {
+	s.elapsed, s.rank, s.done = 0, -1, false
+	s.enemies = s.game.tankDefs.EnemyCount
+}
+
+func (s *Survival) Teams() (TeamSetup, bool) {
+	return TeamSetupByName(DefaultTeams), true
+}
+
+func (s *Survival) SpawnPoints(Team) [][2]float64       { return nil }
+func (s *Survival) Goal(*Tank) (float64, float64, bool) { return 0, 0, false }
+func (s *Survival) Respawn(*Tank) bool                  { return false }
+func (s *Survival) KillWaves() bool                     { return false } // 只按时间进入下一波
+func (s *Survival) DrawWorld(*ebiten.Image)             {}
+
+func (s *Survival) Update() {
+	g := s.game
+	if g.hero.life <= 0 {
+		return
+	}
+	s.elapsed++
+	if s.elapsed%SurvivalWaveTime != 0 {
+		return
+	}
+	g.spawner.Advance()
+	if s.enemies < MaxSurvivalEnemies {
+		g.addEnemy(TeamEnemy, 0)
+		s.enemies++
+	}
+	g.ShowText(fmt.Sprintf("第%d波", g.spawner.WaveNumber()+1), colornames.Orange)
+}
+
+// Pace 按波数而不是得分加快AI
+func (s *Survival) Pace() float64 {
+	return 1 + float64(s.game.spawner.WaveNumber())*SurvivalPace
+}
+
+func (s *Survival) Finish() {
+	if s.done {
+		return
+	}
+	s.done = true
+	entry := LeaderEntry{Player: PlayerName(), Value: s.elapsed, Date: time.Now()}
+	s.rank = s.game.leaderboard.Submit(s.Name(), entry, false)
+}
+
+func (s *Survival) Result() []string {
+	lines := []string{fmt.Sprintf("坚持 %s，到达第%d波", formatClock(s.elapsed), s.game.spawner.WaveNumber()+1)}
+	return append(lines, s.game.leaderboard.Lines(s.Name(), s.rank, LeaderboardShown, formatClock)...)
+}
+
+func (s *Survival) DrawHUD(screen *ebiten.Image) {
+	g := s.game
+	drawHUDLine(screen, g, fmt.Sprintf("生存 %s   第%d波", formatClock(s.elapsed),
+		g.spawner.WaveNumber()+1), 0, colornames.Yellow)
+	next := (SurvivalWaveTime - s.elapsed%SurvivalWaveTime + 59) / 60
+	drawHUDLine(screen, g, fmt.Sprintf("下一波 %d秒", next), 1, colornames.Lightgray)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// updateControls 玩家和敌人按加入世界的顺序行动，回合结束后停止
+func (g *Game) updateControls() {
+	Each(g.world, g.world.ais, func(_ Entity, ai Controller) {
+		if !g.pause {
+			ai.Update()
+		}
+	})
+}
+
+// updateHealth 推进所有实体的受击保护和爆炸动画，回合结束后停止
+func (g *Game) updateHealth() {
+	Each(g.world, g.world.healths, func(_ Entity, health *Health) {
+		if g.pause {
+			return
+		}
+		if health.hitStatus > 0 {
+			health.hitStatus--
+		}
+		if health.dying != nil {
+			health.dying.Update()
+		}
+	})
+}
+
+// updateWeapons 活着的实体的武器逐帧冷却，被暴击时先恢复射击能力
+func (g *Game) updateWeapons() {
+	w := g.world
+	Each(w, w.weapons, func(id Entity, gun *Gun) {
+		if health, ok := w.healths[id]; ok && health.life <= 0 {
+			return
+		}
+		if gun.fireStun > 0 {
+			gun.fireStun--
+		} else if gun.shootCool < ShootCooled {
+			gun.shootCool += gun.shootCoolDown
+		}
+	})
+}
+
+// updateBullets 分多次移动子弹避免跳过碰撞，失效的子弹在帧末移除
+func (g *Game) updateBullets() {
+	w := g.world
+	for i := 0; i < 4; i++ {
+		Each(w, w.bullets, func(id Entity, b *Bullet) {
+			b.AutoMove()
+			b.HitCheck()
+			if !b.alive {
+				w.Remove(id)
+			}
+		})
+	}
+}
+
+// drawEntities 按层绘制所有带绘制组件的实体，障碍物画在地面缓存上
+func (g *Game) drawEntities(screen *ebiten.Image) {
+	for layer := 0; layer < LayerCount; layer++ {
+		Each(g.world, g.world.sprites, func(_ Entity, sprite Sprite) {
+			if sprite.Layer == layer {
+				sprite.Draw(screen)
+			}
+		})
+	}
+}
@@ -2,15 +2,14 @@ package main
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/text"
 	"math"
 	"math/rand"
-	"strconv"
 )
 
 const (
-	ShootCooled  = 180
-	DieHitStatus = 30
+	ShootCooled    = 180
+	ArriveDistance = 24 // 离目标位置多近算到达
+	DetourFrames   = 30 // 被阻挡后沿另一轴绕行的帧数
 )
 
 var (
@@ -20,98 +19,97 @@ var (
 	keyRightUpdates int64
 )
 
+// Tank 坦克由位置、生命和武器组件组成，加入世界后由系统更新
 type Tank struct {
 	*BoxSprite
-	typ           int
-	game          *Game
-	life          int
-	maxLife       int
-	speed         float64
-	bulletSize    float64
-	bulletSpeed   float64
-	bullet        *Bullet
-	shootCool     int           // 射击冷却程度
-	shootCoolDown int           // 射击冷却速度
-	hitSprites    [5]SpriteInfo // 爆炸动画，被击中且死亡时播放
-	hitStatus     int           // 大于0表示被击中，免疫攻击
-	hitProtect    int           // 击中后的免疫时间
+	*Health
+	*Gun
+	id        Entity
+	def       *TankDef
+	game      *Game
+	moveStun  int // 大于0表示被暴击后无法移动
+	speed     float64
+	vision    float64   // 视野半径
+	tracks    *Animator // 移动时留下的履带印，每留下一个前进一帧
+	trackDist float64   // 距上一个履带印移动的距离
+	detour    int       // 大于0表示被阻挡后正在沿另一轴绕行
+	slideX    float64   // 上一帧的实际速度，抓地力不足时保持惯性
+	slideY    float64
 }
 
 type Hero struct {
 	*Tank
 }
 
+// Enemy AI控制的坦克，按阵营设置也可能是英雄的队友或中立坦克
 type Enemy struct {
 	*Tank
 }
 
+// addTank 把坦克和它的组件加入世界
+func (g *Game) addTank(tk *Tank, team Team, ai Controller) {
+	w := g.world
+	tk.id = w.Create()
+	w.transforms[tk.id] = tk.BoxSprite
+	w.sprites[tk.id] = Sprite{Layer: LayerTank, Draw: tk.Draw}
+	w.colliders[tk.id] = &Collider{Solid: true}
+	w.healths[tk.id] = tk.Health
+	w.weapons[tk.id] = tk.Gun
+	w.ais[tk.id] = ai
+	w.teams[tk.id] = team
+	w.tanks[tk.id] = tk
+}
+
 func (tk *Tank) Draw(screen *ebiten.Image) {
-	if tk.hitStatus > 0 {
-		if tk.life > 0 {
-			tk.BoxSprite.Draw(screen)
-			tk.BoxSprite.DrawBorder(screen)
-		} else {
-			options := &ebiten.DrawImageOptions{}
-			options.GeoM.Translate(tk.X, tk.Y)
-			sprite := tk.hitSprites[(tk.hitStatus*len(tk.hitSprites)-1)/DieHitStatus]
-			screen.DrawImage(GetSpriteImage(tk.game.spriteImages, sprite), options)
-		}
+	cam := tk.game.camera
+	if (tk.life <= 0 && tk.dying == nil) || !tk.game.fog.Visible(tk.BoxSprite) {
+		return // 等待重生或在视野外
+	}
+	if tk.dying != nil {
+		tk.dying.Draw(screen, cam)
+	} else if tk.hitStatus > 0 {
+		tk.BoxSprite.Draw(screen, cam)
+		tk.BoxSprite.DrawBorder(screen, cam)
 	} else {
-		tk.BoxSprite.Draw(screen)
+		tk.BoxSprite.Draw(screen, cam)
 	}
 	if tk.life > 0 {
-		text.Draw(screen, strconv.Itoa(tk.life), tk.game.chsFont,
-			int(tk.X+float64(tk.W)/2-5), int(tk.Y+float64(tk.H)/2+5),
-			LifeColors[(tk.life*len(LifeColors)-1)/tk.maxLife])
-	}
-	bullet := tk.bullet
-	for bullet != nil {
-		bullet.Draw(screen)
-		bullet = bullet.next
+		tk.DrawLifeBar(screen)
 	}
 }
 
+// CollideOthers 与阻挡移动的其他实体的碰撞检测
 func (tk *Tank) CollideOthers() (minX, minY, maxX, maxY float64) {
-	// 与其他坦克的碰撞检测
-	for other := tk.game.enemy; other != nil; other = other.Next {
-		if tk != other.Value.Tank && other.Value.life > 0 {
-			if cx, cy := tk.CollideXY(other.Value.BoxSprite); cx != 0 && cy != 0 {
-				maxX = math.Max(cx, maxX)
-				minX = math.Min(cx, minX)
-				maxY = math.Max(cy, maxY)
-				minY = math.Min(cy, minY)
-			}
-		}
-	}
-
-	// 敌人需要判断与英雄的碰撞
-	if tk != tk.game.hero.Tank {
-		if cx, cy := tk.CollideXY(tk.game.hero.Tank.BoxSprite); cx != 0 && cy != 0 {
-			maxX = math.Max(cx, maxX)
-			minX = math.Min(cx, minX)
-			maxY = math.Max(cy, maxY)
-			minY = math.Min(cy, minY)
+	w := tk.game.world
+	Each(w, w.colliders, func(id Entity, _ *Collider) {
+		if id == tk.id || !w.Blocking(id) {
+			return
 		}
-	}
-
-	// 坦克与树的碰撞检测
-	for tree := tk.game.ground.trees; tree != nil; tree = tree.Next {
-		if cx, cy := tk.CollideXY(tree.Value); cx != 0 && cy != 0 {
+		if cx, cy := tk.CollideXY(w.transforms[id]); cx != 0 && cy != 0 {
 			maxX = math.Max(cx, maxX)
 			minX = math.Min(cx, minX)
 			maxY = math.Max(cy, maxY)
 			minY = math.Min(cy, minY)
 		}
-	}
+	})
 	return
 }
 
+// Update 玩家控制移动和射击
+func (h *Hero) Update() {
+	h.tickStun()
+	h.UpdateMove()
+	h.UpdateShoot()
+}
+
 func (h *Hero) UpdateMove() {
-	if h.life < 1 {
+	if h.life <= 0 || h.moveStun > 0 || h.game.command.Open() {
 		return
 	}
 	minKeyUpdates := getMinKeyUpdates()
-	if minKeyUpdates < math.MaxInt64 {
+	if minKeyUpdates == math.MaxInt64 {
+		h.Tank.Coast() // 松开按键后在油污上继续滑行
+	} else {
 		// 控制坦克方向
 		if minKeyUpdates == keyUpUpdates {
 			h.A = AnglePi
@@ -181,39 +179,140 @@ func minInt64(a, b int64) int64 {
 	return b
 }
 
+// Update 敌人自动移动和射击
+func (e *Enemy) Update() {
+	e.tickStun()
+	e.AutoMove()
+	e.AutoShoot()
+}
+
 func (e *Enemy) AutoMove() {
-	if e.life < 1 {
+	if e.life <= 0 || e.moveStun > 0 {
+		return
+	}
+	e.speed = e.def.Speed * e.game.objective.Pace()
+	// 有游戏目标时看不到敌对坦克就去目标位置，看得到则照常交战
+	if x, y, ok := e.game.objective.Goal(e.Tank); ok && e.nearestHostile() == nil {
+		e.goTo(x, y)
 		return
 	}
-	if e.game.updates%(1+rand.Intn(180)) == 0 {
+	if e.game.updates%(1+rand.Intn(max(e.def.AI.TurnRate, 1))) == 0 {
 		e.A = TankAngles[rand.Intn(len(TankAngles))]
+		// 开启迷雾时看得到敌对坦克就转向最近的一辆，没有迷雾时和原来一样随机转向
+		if target := e.nearestHostile(); e.game.fog.enabled && e.def.AI.Aim && target != nil {
+			e.A = e.angleTo(target.BoxSprite)
+		}
 	}
-	e.speed = TankSpeeds[e.typ] * (1 + float64(e.game.score)/1000)
 	e.Tank.Move()
 }
 
+// Move 朝当前方向移动，速度和操控受脚下地形影响
 func (tk *Tank) Move() {
+	var dx, dy float64
 	if tk.A == AnglePi {
-		tk.Y = tk.Y - tk.speed
+		dy = -tk.speed
 	}
 	if tk.A == AngleZero {
-		tk.Y = tk.Y + tk.speed
+		dy = tk.speed
 	}
 	if tk.A == AngleHalfPi {
-		tk.X = tk.X - tk.speed
+		dx = -tk.speed
 	}
 	if tk.A == AngleTrebleHalfPi {
-		tk.X = tk.X + tk.speed
+		dx = tk.speed
+	}
+	tk.slide(dx, dy)
+}
+
+// Coast 不再驱动时只保留惯性
+func (tk *Tank) Coast() {
+	if tk.slideX != 0 || tk.slideY != 0 {
+		tk.slide(0, 0)
+	}
+}
+
+// slide 按地形的速度倍率和抓地力把期望速度混合到实际速度，再分轴移动并处理碰撞
+func (tk *Tank) slide(dx, dy float64) {
+	w, h := tk.GetDrawWH()
+	terrain := tk.game.ground.TerrainAt(tk.X+w/2, tk.Y+h/2)
+	tk.slideX += (dx*terrain.Speed - tk.slideX) * terrain.Grip
+	tk.slideY += (dy*terrain.Speed - tk.slideY) * terrain.Grip
+	if math.Abs(tk.slideX) < 0.05 {
+		tk.slideX = 0
+	}
+	if math.Abs(tk.slideY) < 0.05 {
+		tk.slideY = 0
+	}
+
+	oldX, oldY := tk.X, tk.Y
+	if tk.slideX != 0 {
+		tk.X = tk.X + tk.slideX
+		minX, _, maxX, _ := tk.CollideOthers()
+		if minX+maxX != 0 {
+			tk.X = tk.X + minX + maxX
+			tk.slideX = 0
+		}
+	}
+	if tk.slideY != 0 {
+		tk.Y = tk.Y + tk.slideY
+		_, minY, _, maxY := tk.CollideOthers()
+		if minY+maxY != 0 {
+			tk.Y = tk.Y + minY + maxY
+			tk.slideY = 0
+		}
+	}
+	// 限制不能超出世界
+	tk.X = math.Max(0, math.Min(tk.X, float64(tk.game.worldWidth)-w))
+	tk.Y = math.Max(0, math.Min(tk.Y, float64(tk.game.worldHeight)-h))
+	tk.game.decals.StampTrack(tk, math.Abs(tk.X-oldX)+math.Abs(tk.Y-oldY))
+}
+
+// angleTo 朝向目标的四个方向之一，取距离较大的轴
+func (tk *Tank) angleTo(s *BoxSprite) float64 {
+	dx, dy := s.X-tk.X, s.Y-tk.Y
+	if math.Abs(dx) > math.Abs(dy) {
+		if dx < 0 {
+			return AngleHalfPi
+		}
+		return AngleTrebleHalfPi
+	}
+	if dy < 0 {
+		return AnglePi
+	}
+	return AngleZero
+}
+
+// axisAngle 沿水平或垂直方向朝向偏移量的角度
+func axisAngle(dx, dy float64, horizontal bool) float64 {
+	if horizontal {
+		if dx < 0 {
+			return AngleHalfPi
+		}
+		return AngleTrebleHalfPi
+	}
+	if dy < 0 {
+		return AnglePi
+	}
+	return AngleZero
+}
+
+// goTo 沿距离较大的轴驶向目标位置，被阻挡时改走另一轴绕行，返回是否已经到达
+func (tk *Tank) goTo(x, y float64) bool {
+	cx, cy := center(tk.BoxSprite)
+	dx, dy := x-cx, y-cy
+	if math.Hypot(dx, dy) < ArriveDistance {
+		tk.Coast()
+		return true
 	}
-	minX, minY, maxX, maxY := tk.CollideOthers()
-	if tk.A == AnglePi || tk.A == AngleZero {
-		tk.Y = tk.Y + minY + maxY
+	horizontal := math.Abs(dx) > math.Abs(dy)
+	if tk.detour > 0 {
+		tk.detour--
+		horizontal = !horizontal
 	}
-	if tk.A == AngleHalfPi || tk.A == AngleTrebleHalfPi {
-		tk.X = tk.X + minX + maxX
+	tk.A = axisAngle(dx, dy, horizontal)
+	tk.Move()
+	if tk.slideX == 0 && tk.slideY == 0 && tk.detour == 0 {
+		tk.detour = DetourFrames
 	}
-	// 限制不能超出屏幕
-	dw, dh := tk.GetDrawWH()
-	tk.X = math.Max(0, math.Min(tk.X, float64(tk.game.width)-dw))
-	tk.Y = math.Max(0, math.Min(tk.Y, float64(tk.game.height)-dh))
+	return false
 }
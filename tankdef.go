@@ -0,0 +1,166 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+)
+
+const (
+	TankDefsFile  = "tanks.json" // 工作目录下存在时覆盖内置的坦克定义
+	DefaultVision = 360
+)
+
+//go:embed tanks.json
+var tankDefsData []byte
+
+// AIProfile 敌人的行为参数
+type AIProfile struct {
+	TurnRate int  `json:"turnRate,omitempty"` // 随机转向的最大间隔帧数
+	FireRate int  `json:"fireRate,omitempty"` // 随机射击的最大间隔帧数
+	Aim      bool `json:"aim,omitempty"`      // 看得到英雄时是否转向英雄
+}
+
+// TankDef 一种坦克的定义，新增敌人只需修改数据文件
+type TankDef struct {
+	Name        string    `json:"name,omitempty"`
+	Sprite      string    `json:"sprite,omitempty"`
+	Tracks      string    `json:"tracks,omitempty"`
+	Bullet      string    `json:"bullet,omitempty"`
+	BulletSize  float64   `json:"bulletSize,omitempty"`
+	BulletSpeed float64   `json:"bulletSpeed,omitempty"`
+	Speed       float64   `json:"speed,omitempty"`
+	Life        float64   `json:"life,omitempty"`
+	Armor       float64   `json:"armor,omitempty"`
+	Protect     int       `json:"protect,omitempty"` // 被击中后的免疫帧数
+	Vision      float64   `json:"vision,omitempty"`
+	Weapon      Weapon    `json:"weapon"`
+	AI          AIProfile `json:"ai"`
+	Boss        bool      `json:"boss,omitempty"`        // 出现时切换到紧张的音乐
+	Score       int       `json:"score,omitempty"`       // 击中得分
+	SpawnWeight int       `json:"spawnWeight,omitempty"` // 作为敌人出现的权重，0表示不会出现
+}
+
+// TankDefs 坦克定义的注册表
+type TankDefs struct {
+	Hero       string     `json:"hero,omitempty"`
+	Wingman    string     `json:"wingman,omitempty"` // 僚机使用的定义，省略时没有僚机
+	EnemyCount int        `json:"enemyCount,omitempty"`
+	Tanks      []*TankDef `json:"tanks,omitempty"`
+	byName     map[string]*TankDef
+	weights    int
+}
+
+// LoadTankDefs 优先读取工作目录下的定义文件，否则使用内置定义
+func LoadTankDefs(atlas *Atlas) *TankDefs {
+	data, err := os.ReadFile(TankDefsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		data = tankDefsData
+	} else {
+		FatalIfError(err)
+	}
+	defs, err := ParseTankDefs(data, atlas)
+	FatalIfError(err)
+	return defs
+}
+
+// ParseTankDefs 解析并校验坦克定义
+func ParseTankDefs(data []byte, atlas *Atlas) (*TankDefs, error) {
+	defs := &TankDefs{}
+	if err := json.Unmarshal(data, defs); err != nil {
+		return nil, fmt.Errorf("parse tank defs: %w", err)
+	}
+	defs.byName = make(map[string]*TankDef, len(defs.Tanks))
+	for _, def := range defs.Tanks {
+		if _, ok := defs.byName[def.Name]; ok {
+			return nil, fmt.Errorf("tank def %q: duplicate name", def.Name)
+		}
+		for _, sprite := range []string{def.Sprite, def.Tracks, def.Bullet} {
+			if _, err := atlas.Sprite(sprite); err != nil {
+				return nil, fmt.Errorf("tank def %q: %w", def.Name, err)
+			}
+		}
+		if def.Speed <= 0 || def.Life <= 0 || def.BulletSpeed <= 0 {
+			return nil, fmt.Errorf("tank def %q: speed, life and bulletSpeed must be positive", def.Name)
+		}
+		if def.Vision == 0 {
+			def.Vision = DefaultVision
+		}
+		if def.BulletSize == 0 {
+			def.BulletSize = 1
+		}
+		if def.Weapon.Damage == 0 {
+			def.Weapon.Damage = 1
+		}
+		if def.BulletSize < 0 || def.Weapon.Damage < 0 {
+			return nil, fmt.Errorf("tank def %q: bulletSize and weapon damage must be positive", def.Name)
+		}
+		if def.Armor < 0 || def.Armor >= 1 {
+			return nil, fmt.Errorf("tank def %q: armor must be in [0, 1)", def.Name)
+		}
+		defs.byName[def.Name] = def
+		defs.weights += def.SpawnWeight
+	}
+	if defs.byName[defs.Hero] == nil {
+		return nil, fmt.Errorf("hero tank def %q not found", defs.Hero)
+	}
+	if defs.Wingman != "" && defs.byName[defs.Wingman] == nil {
+		return nil, fmt.Errorf("wingman tank def %q not found", defs.Wingman)
+	}
+	if defs.weights <= 0 {
+		return nil, errors.New("no tank def has a positive spawnWeight")
+	}
+	return defs, nil
+}
+
+func (d *TankDefs) Get(name string) *TankDef {
+	return d.byName[name]
+}
+
+// RandomEnemy 按出现权重随机选择一种敌人
+func (d *TankDefs) RandomEnemy() *TankDef {
+	n := rand.Intn(d.weights)
+	for _, def := range d.Tanks {
+		if n < def.SpawnWeight {
+			return def
+		}
+		n -= def.SpawnWeight
+	}
+	return d.Tanks[len(d.Tanks)-1]
+}
+
+// newTank 按定义创建坦克，位置由调用者决定
+func (g *Game) newTank(def *TankDef) *Tank {
+	sprite := g.sprite(def.Sprite)
+	_, size := sprite.Size()
+	tk := &Tank{
+		BoxSprite: &BoxSprite{
+			Img: g.atlas.Image(sprite),
+			W:   float64(size),
+			H:   float64(size),
+		},
+		Health: &Health{
+			life:       def.Life,
+			maxLife:    def.Life,
+			armor:      def.Armor,
+			hitProtect: def.Protect,
+		},
+		Gun: &Gun{
+			weapon:        def.Weapon,
+			bulletSize:    def.BulletSize,
+			bulletSpeed:   def.BulletSpeed,
+			shootCoolDown: int(def.BulletSpeed),
+		},
+		def:    def,
+		game:   g,
+		speed:  def.Speed,
+		vision: def.Vision,
+		tracks: g.animation(def.Tracks).Play(nil),
+	}
+	tk.onHit = tk.hitEffect
+	tk.SetPivot(sprite)
+	return tk
+}
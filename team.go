@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math"
+)
+
+const DefaultTeams = "classic"
+
+// TeamSetups 可选的阵营设置，在选项中切换，重开后生效
+var TeamSetups = []TeamSetup{
+	{Name: "classic", Label: "经典", Enemies: []Team{TeamEnemy}, Hostile: [][2]Team{{TeamHero, TeamEnemy}}},
+	{Name: "ffa", Label: "混战", FreeForAll: true},
+	{Name: "2v2", Label: "2对2", Count: 3, Enemies: []Team{TeamHero, TeamEnemy, TeamEnemy},
+		Hostile: [][2]Team{{TeamHero, TeamEnemy}}},
+	{Name: "neutral", Label: "中立", Enemies: []Team{TeamEnemy, TeamEnemy, TeamNeutral},
+		Hostile: [][2]Team{{TeamHero, TeamEnemy}}},
+}
+
+// TeamSetup 阵营设置，Enemies按顺序循环分配给AI坦克，
+// 混战时每辆AI坦克单独一个阵营，所有阵营互相敌对
+type TeamSetup struct {
+	Name       string    `json:"name"`
+	Label      string    `json:"label"`
+	Count      int       `json:"count,omitempty"` // AI坦克的数量，0表示使用坦克定义中的数量
+	Enemies    []Team    `json:"enemies,omitempty"`
+	Hostile    [][2]Team `json:"hostile,omitempty"` // 互相敌对的阵营
+	FreeForAll bool      `json:"freeForAll,omitempty"`
+}
+
+// TeamSetupByName 按名称查找阵营设置，找不到时使用经典设置
+func TeamSetupByName(name string) TeamSetup {
+	for _, setup := range TeamSetups {
+		if setup.Name == name {
+			return setup
+		}
+	}
+	return TeamSetups[0]
+}
+
+// EnemyTeam 第i辆AI坦克所属的阵营
+func (s TeamSetup) EnemyTeam(i int) Team {
+	if s.FreeForAll || len(s.Enemies) == 0 {
+		return TeamEnemy + Team(i)
+	}
+	return s.Enemies[i%len(s.Enemies)]
+}
+
+// Hostility 阵营之间的敌对关系，对称且不能与自己敌对，
+// 没有设置的阵营之间按默认值，中立阵营默认不与任何阵营敌对
+type Hostility struct {
+	pairs    map[[2]Team]bool
+	fallback bool
+}
+
+func NewHostility(setup TeamSetup) *Hostility {
+	h := &Hostility{pairs: map[[2]Team]bool{}, fallback: setup.FreeForAll}
+	for _, pair := range setup.Hostile {
+		h.Set(pair[0], pair[1], true)
+	}
+	return h
+}
+
+func teamPair(a, b Team) [2]Team {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]Team{a, b}
+}
+
+func (h *Hostility) Set(a, b Team, hostile bool) {
+	h.pairs[teamPair(a, b)] = hostile
+}
+
+func (h *Hostility) Hostile(a, b Team) bool {
+	if a == b || a == TeamNone || b == TeamNone {
+		return false
+	}
+	if hostile, ok := h.pairs[teamPair(a, b)]; ok {
+		return hostile
+	}
+	return h.fallback && a != TeamNeutral && b != TeamNeutral
+}
+
+// Peaceful 阵营是否不与任何阵营敌对
+func (h *Hostility) Peaceful(team Team) bool {
+	for pair, hostile := range h.pairs {
+		if hostile && (pair[0] == team || pair[1] == team) {
+			return false
+		}
+	}
+	return !h.fallback || team == TeamNeutral
+}
+
+// hostile 两个实体是否敌对
+func (g *Game) hostile(a, b Entity) bool {
+	return g.hostility.Hostile(g.world.teams[a], g.world.teams[b])
+}
+
+// canHit 子弹能否击中实体，开启友军伤害时也能击中同阵营的其他坦克
+func (g *Game) canHit(b *Bullet, id Entity) bool {
+	if g.hostile(b.id, id) {
+		return true
+	}
+	_, tank := g.world.tanks[id]
+	team := g.world.teams[b.id]
+	return tank && g.settings.FriendlyFire && id != b.tank.id && team != TeamNone && g.world.teams[id] == team
+}
+
+// nearestHostile 视野内最近的活着的敌对坦克，没有则返回nil
+func (tk *Tank) nearestHostile() *Tank {
+	w := tk.game.world
+	var nearest *Tank
+	best := math.Inf(1)
+	Each(w, w.tanks, func(id Entity, other *Tank) {
+		if other.life <= 0 || !tk.game.hostile(tk.id, id) || !tk.CanSee(other.BoxSprite) {
+			return
+		}
+		if dist := math.Hypot(other.X-tk.X, other.Y-tk.Y); dist < best {
+			nearest, best = other, dist
+		}
+	})
+	return nearest
+}
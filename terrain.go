@@ -0,0 +1,132 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	TileSize  = 128          // 地形格子的边长，与地面贴图一致
+	LevelFile = "level.json" // 工作目录下存在时覆盖内置的关卡
+	GrassTile = "."          // 超出布局的部分使用的地形
+)
+
+//go:embed level.json
+var levelData []byte
+
+// Terrain 地形属性，影响坦克在上面的移动
+type Terrain struct {
+	Name    string  `json:"name,omitempty"`
+	Sprite  string  `json:"sprite,omitempty"`  // 地面贴图
+	Overlay string  `json:"overlay,omitempty"` // 叠加在地面上的贴图，例如油污
+	Speed   float64 `json:"speed,omitempty"`   // 移动速度倍率，省略时为1
+	Grip    float64 `json:"grip,omitempty"`    // 抓地力，1为完全控制，越小越容易打滑，省略时为1
+}
+
+// Level 关卡的地形布局、出生点和每一波的重生节奏，
+// 每个字符代表一格地形，超出布局的部分为草地
+type Level struct {
+	Terrains    map[string]*Terrain `json:"terrains,omitempty"`    // 键为布局中代表这种地形的字符
+	SpawnPoints [][2]float64        `json:"spawnPoints,omitempty"` // 出生点的位置，按世界尺寸的比例
+	Waves       []SpawnWave         `json:"waves,omitempty"`       // 击毁足够数量后进入下一波，最后一波一直持续
+	Tiles       []string            `json:"tiles,omitempty"`
+}
+
+// LoadLevel 优先读取工作目录下的关卡文件，否则使用内置关卡
+func LoadLevel(atlas *Atlas) *Level {
+	data, err := os.ReadFile(LevelFile)
+	if errors.Is(err, os.ErrNotExist) {
+		data = levelData
+	} else {
+		FatalIfError(err)
+	}
+	level, err := ParseLevel(data, atlas)
+	FatalIfError(err)
+	return level
+}
+
+// ParseLevel 解析并校验关卡，省略的速度和抓地力为1，出生点和波次不能为空
+func ParseLevel(data []byte, atlas *Atlas) (*Level, error) {
+	level := &Level{}
+	if err := json.Unmarshal(data, level); err != nil {
+		return nil, fmt.Errorf("parse level: %w", err)
+	}
+	for key, terrain := range level.Terrains {
+		if len(key) != 1 {
+			return nil, fmt.Errorf("terrain %q: key must be a single character", key)
+		}
+		if terrain == nil {
+			return nil, fmt.Errorf("terrain %q: missing definition", key)
+		}
+		sprites := []string{terrain.Sprite}
+		if terrain.Overlay != "" {
+			sprites = append(sprites, terrain.Overlay)
+		}
+		for _, sprite := range sprites {
+			if _, err := atlas.Sprite(sprite); err != nil {
+				return nil, fmt.Errorf("terrain %q: %w", key, err)
+			}
+		}
+		if terrain.Speed == 0 {
+			terrain.Speed = 1
+		}
+		if terrain.Grip == 0 {
+			terrain.Grip = 1
+		}
+		if terrain.Speed < 0 || terrain.Grip < 0 || terrain.Grip > 1 {
+			return nil, fmt.Errorf("terrain %q: speed must be positive and grip in (0, 1]", key)
+		}
+	}
+	if level.Terrains[GrassTile] == nil {
+		return nil, fmt.Errorf("level: terrain %q is required", GrassTile)
+	}
+	if len(level.SpawnPoints) == 0 {
+		return nil, errors.New("level: no spawn points")
+	}
+	for i, point := range level.SpawnPoints {
+		if point[0] < 0 || point[0] > 1 || point[1] < 0 || point[1] > 1 {
+			return nil, fmt.Errorf("level spawn point %d: position must be within [0, 1]", i)
+		}
+	}
+	if len(level.Waves) == 0 {
+		return nil, errors.New("level: no spawn waves")
+	}
+	for i, wave := range level.Waves {
+		if wave.Kills < 0 || wave.RebornDelay < 0 || wave.WarnTime < 0 {
+			return nil, fmt.Errorf("level wave %d: kills and delays must not be negative", i)
+		}
+	}
+	for row, line := range level.Tiles {
+		for col := 0; col < len(line); col++ {
+			if level.Terrains[line[col:col+1]] == nil {
+				return nil, fmt.Errorf("level tile %d,%d: unknown terrain %q", col, row, line[col:col+1])
+			}
+		}
+	}
+	return level, nil
+}
+
+// initTerrain 按关卡布局生成地形格子
+func (g *Ground) initTerrain(level *Level) {
+	g.cols = (g.game.worldWidth + TileSize - 1) / TileSize
+	g.rows = (g.game.worldHeight + TileSize - 1) / TileSize
+	g.tiles = make([]*Terrain, g.cols*g.rows)
+	for row := 0; row < g.rows; row++ {
+		for col := 0; col < g.cols; col++ {
+			g.tiles[row*g.cols+col] = level.Terrains[GrassTile]
+			if row < len(level.Tiles) && col < len(level.Tiles[row]) {
+				g.tiles[row*g.cols+col] = level.Terrains[level.Tiles[row][col:col+1]]
+			}
+		}
+	}
+}
+
+// TerrainAt 世界坐标所在格子的地形
+func (g *Ground) TerrainAt(x, y float64) *Terrain {
+	col := max(0, min(int(x)/TileSize, g.cols-1))
+	row := max(0, min(int(y)/TileSize, g.rows-1))
+	return g.tiles[row*g.cols+col]
+}
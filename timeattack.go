@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"time"
+)
+
+const (
+	TimeAttackKills = 20 // 计时赛需要击毁的敌人数量
+	SplitEvery      = 5  // 每击毁这么多辆记录一次分段用时
+)
+
+// TimeAttack 计时赛，尽快击毁指定数量的敌人，每隔几辆记录分段用时并与最好成绩比较，
+// 完成时按用时记入排行榜，英雄阵亡则不记录
+type TimeAttack struct {
+	game       *Game
+	elapsed    int
+	splits     []int
+	bestSplits []int // 最好成绩的分段用时，本局开始时取得
+	cleared    bool
+	rank       int
+	done       bool
+}
+
+func NewTimeAttack(g *Game) *TimeAttack {
+	return &TimeAttack{game: g}
+}
+
+func (t *TimeAttack) Name() string {
+	return "timeattack"
+}
+
+func (t *TimeAttack) Reset() {
+	t.elapsed, t.splits, t.bestSplits = 0, nil, nil
+	t.cleared, t.rank, t.done = false, -1, false
+	if best := t.game.leaderboard.Best(t.Name()); best != nil {
+		t.bestSplits = best.Splits
+	}
+}
+
+func (t *TimeAttack) Teams() (TeamSetup, bool) {
+	return TeamSetupByName(DefaultTeams), true
+}
+
+func (t *TimeAttack) SpawnPoints(Team) [][2]float64       { return nil }
+func (t *TimeAttack) Goal(*Tank) (float64, float64, bool) { return 0, 0, false }
+func (t *TimeAttack) Respawn(*Tank) bool                  { return false }
+func (t *TimeAttack) KillWaves() bool                     { return true }
+func (t *TimeAttack) DrawWorld(*ebiten.Image)             {}
+
+func (t *TimeAttack) Pace() float64 {
+	return scorePace(t.game)
+}
+
+func (t *TimeAttack) Update() {
+	g := t.game
+	if t.cleared || g.hero.life <= 0 {
+		return
+	}
+	t.elapsed++
+	kills := g.scoring.kills
+	for len(t.splits) < kills/SplitEvery {
+		t.splits = append(t.splits, t.elapsed)
+		g.ShowText(fmt.Sprintf("%d辆 %s", len(t.splits)*SplitEvery, formatSplit(t.elapsed)), colornames.Yellow)
+	}
+	if kills >= TimeAttackKills {
+		t.cleared = true
+		g.EndRound()
+	}
+}
+
+func (t *TimeAttack) Finish() {
+	if t.done {
+		return
+	}
+	t.done = true
+	if t.cleared {
+		entry := LeaderEntry{Player: PlayerName(), Value: t.elapsed, Splits: t.splits, Date: time.Now()}
+		t.rank = t.game.leaderboard.Submit(t.Name(), entry, true)
+	}
+}
+
+// delta 分段用时与最好成绩的差，没有可比较的记录时返回空
+func (t *TimeAttack) delta(i int) string {
+	if i >= len(t.bestSplits) {
+		return ""
+	}
+	return fmt.Sprintf("%+.2f", float64(t.splits[i]-t.bestSplits[i])/60)
+}
+
+func (t *TimeAttack) Result() []string {
+	var lines []string
+	if t.cleared {
+		lines = append(lines, fmt.Sprintf("击毁%d辆用时 %s", TimeAttackKills, formatSplit(t.elapsed)))
+	} else {
+		lines = append(lines, fmt.Sprintf("未完成：击毁 %d/%d", t.game.scoring.kills, TimeAttackKills))
+	}
+	for i, split := range t.splits {
+		lines = append(lines, fmt.Sprintf("  %d辆 %s %s", (i+1)*SplitEvery, formatSplit(split), t.delta(i)))
+	}
+	return append(lines, t.game.leaderboard.Lines(t.Name(), t.rank, LeaderboardShown, formatSplit)...)
+}
+
+// DrawHUD 显示击毁进度和用时，下面列出分段用时，比最好成绩快为绿色，慢为红色
+func (t *TimeAttack) DrawHUD(screen *ebiten.Image) {
+	g := t.game
+	drawHUDLine(screen, g, fmt.Sprintf("计时赛 击毁 %d/%d   %s", min(g.scoring.kills, TimeAttackKills),
+		TimeAttackKills, formatSplit(t.elapsed)), 0, colornames.Yellow)
+	for i, split := range t.splits {
+		clr := colornames.Lightgray
+		if i < len(t.bestSplits) && split < t.bestSplits[i] {
+			clr = colornames.Limegreen
+		} else if i < len(t.bestSplits) && split > t.bestSplits[i] {
+			clr = colornames.Orangered
+		}
+		drawHUDLine(screen, g, fmt.Sprintf("%d辆 %s %s", (i+1)*SplitEvery, formatSplit(split), t.delta(i)), i+1, clr)
+	}
+}
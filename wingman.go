@@ -0,0 +1,173 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/colornames"
+	"math"
+)
+
+const (
+	MaxWingmen   = 3
+	WingmanLeash = 360 // 跟随时离开编队位置追击敌人的最大距离
+)
+
+// Order 给僚机的命令
+type Order int
+
+const (
+	OrderFollow Order = iota // 保持编队跟随英雄，追击附近的敌人
+	OrderHold                // 在下令的位置坚守，只向对齐的敌人射击
+	OrderAttack              // 攻击指定的目标，目标被摧毁后恢复跟随
+)
+
+var OrderLabels = [...]string{"跟随", "坚守", "攻击"}
+
+// FormationSlots 僚机的编队位置，相对英雄中心向右和向前的距离
+var FormationSlots = [][2]float64{{-90, -90}, {90, -90}, {0, -160}}
+
+// Wingman 英雄的僚机，与英雄同一阵营
+type Wingman struct {
+	*Tank
+	slot    int
+	order   Order
+	holdX   float64 // 坚守位置的中心
+	holdY   float64
+	target  *Tank
+	aligned bool // 目标在炮口方向上，可以射击
+}
+
+// initWingmen 按设置的数量在英雄身后的编队位置创建僚机，阵亡的僚机只在游戏目标允许时重生
+func (g *Game) initWingmen() {
+	g.wingmen = 0
+	def := g.tankDefs.Get(g.tankDefs.Wingman)
+	if def == nil {
+		return
+	}
+	for i := 0; i < min(g.settings.Wingmen, MaxWingmen); i++ {
+		wm := &Wingman{Tank: g.newTank(def), slot: i}
+		wm.A = g.hero.A
+		x, y := wm.post()
+		wm.X = math.Max(0, math.Min(x-wm.W/2, float64(g.worldWidth)-wm.W))
+		wm.Y = math.Max(0, math.Min(y-wm.H/2, float64(g.worldHeight)-wm.H))
+		wm.hitStatus = wm.hitProtect
+		wm.onDeath = func() { g.objective.Respawn(wm.Tank) }
+		g.addTank(wm.Tank, g.world.teams[g.hero.id], wm)
+		g.world.sprites[wm.id] = Sprite{Layer: LayerTank, Draw: wm.Draw}
+		g.wingmen++
+	}
+}
+
+// Command 给所有活着的僚机下命令，坚守的位置是各自当前的位置
+func (g *Game) Command(order Order, target *Tank) {
+	Each(g.world, g.world.ais, func(_ Entity, ai Controller) {
+		wm, ok := ai.(*Wingman)
+		if !ok || wm.life <= 0 {
+			return
+		}
+		wm.order, wm.target = order, target
+		if order == OrderHold {
+			wm.holdX, wm.holdY = center(wm.BoxSprite)
+		}
+	})
+}
+
+func (wm *Wingman) Update() {
+	wm.tickStun()
+	wm.steer()
+	wm.fire()
+}
+
+// steer 按命令移动：攻击目标，追击编队附近的敌人，或者回到编队或坚守的位置
+func (wm *Wingman) steer() {
+	wm.aligned = false
+	if wm.life <= 0 || wm.moveStun > 0 {
+		return
+	}
+	if wm.target != nil && wm.target.life <= 0 {
+		wm.target = nil
+		if wm.order == OrderAttack {
+			wm.order = OrderFollow
+		}
+	}
+	x, y := wm.post()
+	foe := wm.nearestHostile()
+	switch {
+	case wm.order == OrderAttack && wm.target != nil:
+		wm.aim(wm.target, true)
+	case wm.order == OrderFollow && foe != nil && wm.distance(foe, x, y) < WingmanLeash:
+		wm.aim(foe, true)
+	case wm.goTo(x, y) && foe != nil:
+		wm.aim(foe, false)
+	}
+}
+
+func (wm *Wingman) fire() {
+	if wm.life <= 0 || !wm.Ready() {
+		return
+	}
+	if wm.aligned && wm.clearShot() {
+		wm.shootBullet()
+	}
+}
+
+// post 当前命令下应该在的位置
+func (wm *Wingman) post() (float64, float64) {
+	if wm.order == OrderHold {
+		return wm.holdX, wm.holdY
+	}
+	hero := wm.game.hero
+	hx, hy := center(hero.BoxSprite)
+	// 英雄角度为Pi时车头向上，前方为(-sin, cos)，右方为(-cos, -sin)
+	sin, cos := math.Sincos(hero.A)
+	slot := FormationSlots[wm.slot%len(FormationSlots)]
+	return hx - slot[0]*cos - slot[1]*sin, hy - slot[0]*sin + slot[1]*cos
+}
+
+func (wm *Wingman) distance(tk *Tank, x, y float64) float64 {
+	tx, ty := center(tk.BoxSprite)
+	return math.Hypot(tx-x, ty-y)
+}
+
+// aim 与目标在同一行或同一列时转向目标准备射击，否则沿差距较小的轴移动去对齐
+func (wm *Wingman) aim(target *Tank, move bool) {
+	cx, cy := center(wm.BoxSprite)
+	tx, ty := center(target.BoxSprite)
+	dx, dy := tx-cx, ty-cy
+	tolerance := target.W / 2
+	if math.Abs(dx) < tolerance || math.Abs(dy) < tolerance {
+		wm.A = axisAngle(dx, dy, math.Abs(dy) < tolerance)
+		wm.aligned = true
+		wm.Coast()
+		return
+	}
+	if move {
+		wm.A = axisAngle(dx, dy, math.Abs(dx) < math.Abs(dy))
+		wm.Move()
+	}
+}
+
+// clearShot 开启友军伤害时，炮口方向上没有队友才射击
+func (wm *Wingman) clearShot() bool {
+	g := wm.game
+	if !g.settings.FriendlyFire {
+		return true
+	}
+	cx, cy := center(wm.BoxSprite)
+	sin, cos := math.Sincos(wm.A)
+	x, y := cx-sin*wm.vision, cy+cos*wm.vision
+	return Find(g.world, g.world.tanks, func(id Entity, tk *Tank) bool {
+		return id != wm.id && tk.life > 0 && g.world.teams[id] == g.world.teams[wm.id] &&
+			segmentHitsBox(cx, cy, x, y, tk.BoxSprite)
+	}) == 0
+}
+
+// Draw 在僚机上方画一个绿点，与英雄区分
+func (wm *Wingman) Draw(screen *ebiten.Image) {
+	wm.Tank.Draw(screen)
+	if wm.life <= 0 || !wm.game.fog.Visible(wm.BoxSprite) {
+		return
+	}
+	w, _ := wm.GetDrawWH()
+	x, y := wm.game.camera.ToScreen(wm.X+w/2, wm.Y-6)
+	fillCircle(screen, float32(x), float32(y), 4, colornames.Limegreen, true)
+}